@@ -8,19 +8,28 @@ import (
 )
 
 // Arguments reads properties from the command line arguments.
-// Property arguments are expected to have a common prefix and use key=value
-// format. Other arguments are ignored.
+// Property arguments are expected to have a common prefix and use either
+// "key=value" format or provide the value as the next argument. A property
+// argument without a value is treated as a boolean flag with the value "true".
+// Other arguments are ignored, as are all arguments after a "--" terminator.
 //
 // For example, the command:
 //
-//	cmd -a -1 -z --prop.1=a --prop.2=b --prop.3 --log=debug
+//	cmd -a -1 -z --prop.1=a --prop.2 b --prop.3 --log=debug -- --prop.4=d
 //
 // with a prefix of '--prop.' would have properties "1"="a", "2"="b", and
-// "3"="".
+// "3"="true".
+//
+// Since a value may be provided as the next argument, values that start with
+// '-' must use the "key=value" format (such as "--prop.offset=-1").
 type Arguments struct {
 	// Prefix provides the common prefix to use when looking for property
 	// arguments. If not set, the default of '--' will be used.
 	Prefix string
+
+	// Args provides the arguments to read properties from. If nil, the
+	// arguments in os.Args (excluding the program name) will be used.
+	Args []string
 }
 
 // Ensure that Arguments implements PropertyGetter
@@ -30,17 +39,16 @@ var _ PropertyGetter = &Arguments{}
 // the property does not exist, an empty string will be returned. The bool
 // return value indicates whether the property was found.
 func (a *Arguments) Get(key string) (string, bool) {
-	prefix := a.Prefix
-	if prefix == "" {
-		prefix = "--"
-	}
-	prefix = prefix + key + "="
-	for _, val := range os.Args {
-		if strings.HasPrefix(val, prefix) {
-			return val[len(prefix):], true
+	var val string
+	var found bool
+	a.each(func(k, v string) bool {
+		if k == key {
+			val = v
+			found = true
 		}
-	}
-	return "", false
+		return !found
+	})
+	return val, found
 }
 
 // GetDefault retrieves the value of a property from the command line arguments.
@@ -58,18 +66,52 @@ func (a *Arguments) GetDefault(key, defVal string) string {
 // If no values were set, an empty slice is returned.
 func (a *Arguments) Names() []string {
 	result := make([]string, 0, 8)
+	a.each(func(key, _ string) bool {
+		for _, v := range result {
+			if v == key {
+				return true
+			}
+		}
+		result = append(result, key)
+		return true
+	})
+	return result
+}
+
+// each calls fn with the key and value of every property argument in order
+// until fn returns false.
+func (a *Arguments) each(fn func(key, val string) bool) {
 	prefix := a.Prefix
 	if prefix == "" {
 		prefix = "--"
 	}
-	for _, val := range os.Args {
-		if strings.HasPrefix(val, prefix) {
-			val = val[len(prefix):]
-			if strings.Contains(val, "=") {
-				val = val[0:strings.Index(val, "=")]
-			}
-			result = append(result, val)
+	args := a.Args
+	if args == nil && len(os.Args) > 0 {
+		args = os.Args[1:]
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if !strings.HasPrefix(arg, prefix) || len(arg) == len(prefix) {
+			continue
+		}
+
+		key := arg[len(prefix):]
+		var val string
+		if eq := strings.Index(key, "="); eq >= 0 {
+			key, val = key[:eq], key[eq+1:]
+		} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			val = args[i+1]
+			i++
+		} else {
+			val = "true"
+		}
+
+		if !fn(key, val) {
+			return
 		}
 	}
-	return result
 }
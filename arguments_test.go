@@ -87,3 +87,60 @@ func TestArgumentsNamesNone(t *testing.T) {
 		t.Errorf("want: %v; got: %v", want, got)
 	}
 }
+
+func TestArgumentsArgs(t *testing.T) {
+	a := &Arguments{
+		Prefix: "--prop.",
+		Args: []string{"-a", "-1", "-z", "--prop.1=a", "--prop.2", "b", "--prop.3",
+			"--log=debug", "--prop.4", "--prop.1=z", "--prop.5=x=y", "--prop.", "--", "--prop.6=f"},
+	}
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"1", "a", true},
+		{"2", "b", true},
+		{"3", "true", true},
+		{"4", "true", true},
+		{"5", "x=y", true},
+		{"6", "", false},
+		{"log", "", false},
+	}
+	for _, test := range tests {
+		val, ok := a.Get(test.key)
+		if val != test.want || ok != test.found {
+			t.Errorf("%s want: %t, '%s'; got: %t, '%s'", test.key, test.found, test.want, ok, val)
+		}
+	}
+
+	got := a.Names()
+	want := []string{"1", "2", "3", "4", "5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+func TestArgumentsDefaultPrefix(t *testing.T) {
+	a := &Arguments{Args: []string{"--verbose", "--name", "value", "--", "--other"}}
+
+	val, ok := a.Get("verbose")
+	if !ok || val != "true" {
+		t.Errorf("want: true, 'true'; got: %t, '%s'", ok, val)
+	}
+	val, ok = a.Get("name")
+	if !ok || val != "value" {
+		t.Errorf("want: true, 'value'; got: %t, '%s'", ok, val)
+	}
+	val, ok = a.Get("other")
+	if ok || val != "" {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+
+	got := a.Names()
+	want := []string{"verbose", "name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
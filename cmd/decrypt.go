@@ -4,14 +4,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
-
-	"github.com/rickar/props"
 )
 
 var (
-	decryptFlags = flag.NewFlagSet("decrypt", flag.ExitOnError)
-	decryptValue = decryptFlags.String("value", "", "`encrypted` value to decrypt (including algorithm prefix)")
-	decryptPass  = decryptFlags.String("password", "", "`password` to decrypt the value")
+	decryptFlags   = flag.NewFlagSet("decrypt", flag.ExitOnError)
+	decryptValue   = decryptFlags.String("value", "", "`encrypted` value to decrypt (including algorithm prefix)")
+	decryptPass    = decryptFlags.String("password", "", "`password` to decrypt the value")
+	decryptKeySrc  = decryptFlags.String("keysrc", "", "`source` of the key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	decryptKey     = decryptFlags.String("key", "", "property `key` the value is bound to for bound algorithms")
+	decryptContext = decryptFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
 )
 
 func init() {
@@ -27,11 +28,12 @@ func decrypt() {
 		decryptFlags.Usage()
 		os.Exit(100)
 	}
-	if *decryptPass == "" {
+	keys := keySource(*decryptKeySrc, decryptFlags, 100)
+	if keys == nil && *decryptPass == "" {
 		*decryptPass = readPassword("Password:", decryptFlags, 100)
 	}
 
-	dec, err := props.Decrypt(*decryptPass, *decryptValue)
+	dec, err := decryptWith(keys, *decryptPass, *decryptValue, *decryptKey, splitContext(*decryptContext))
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "decrypt error: %v\n", err)
 		os.Exit(101)
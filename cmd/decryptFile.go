@@ -6,16 +6,17 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/rickar/props"
 )
 
 var (
-	decryptFileFlags  = flag.NewFlagSet("decryptFile", flag.ExitOnError)
-	decryptFilePath   = decryptFileFlags.String("path", "", "properties `file` to decrypt")
-	decryptFilePass   = decryptFileFlags.String("password", "", "`password` to decrypt the values")
-	decryptFileOutput = decryptFileFlags.String("output", "", "output `file` to write results (default is input file)")
+	decryptFileFlags   = flag.NewFlagSet("decryptFile", flag.ExitOnError)
+	decryptFilePath    = decryptFileFlags.String("path", "", "properties `file` to decrypt")
+	decryptFilePass    = decryptFileFlags.String("password", "", "`password` to decrypt the values")
+	decryptFileKeySrc  = decryptFileFlags.String("keysrc", "", "`source` of the key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	decryptFileOutput  = decryptFileFlags.String("output", "", "output `file` to write results (default is input file)")
+	decryptFileContext = decryptFileFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
 )
 
 func init() {
@@ -38,13 +39,22 @@ func decryptFile() {
 			os.Exit(201)
 		}
 	}
-	if *decryptFilePass == "" {
+	keys := keySource(*decryptFileKeySrc, decryptFileFlags, 202)
+	if keys == nil && *decryptFilePass == "" {
 		*decryptFilePass = readPassword("Password:", decryptFileFlags, 202)
 	}
 	if *decryptFileOutput == "" {
 		*decryptFileOutput = *decryptFilePath
 	}
 
+	var dk props.KeyProvider = keys
+	if found, err := fileDataKey(*decryptFilePath, masterKey(keys, "", *decryptFilePass)); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read data key: %v\n", err)
+		os.Exit(206)
+	} else if found != nil {
+		dk = found
+	}
+
 	f, err := os.Open(*decryptFilePath)
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property file: %v\n", err)
@@ -57,12 +67,11 @@ func decryptFile() {
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.Contains(line, props.EncryptAESGCM) {
+		if i := encryptedIndex(line); i >= 0 && !props.IsDataKeyHeader(line) {
 			found++
-			i := strings.Index(line, props.EncryptAESGCM)
 			val := line[i:]
 			line := line[:i]
-			enc, err := props.Decrypt(*decryptFilePass, val)
+			enc, err := decryptWith(dk, *decryptFilePass, val, lineKey(line, i), splitContext(*decryptFileContext))
 			if err != nil {
 				fmt.Fprintf(flag.CommandLine.Output(), "unable to decrypt property: %v\n", err)
 				os.Exit(204)
@@ -4,15 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rickar/props"
 )
 
 var (
-	encryptFlags = flag.NewFlagSet("encrypt", flag.ExitOnError)
-	encryptValue = encryptFlags.String("value", "", "`plaintext` value to encrypt")
-	encryptPass  = encryptFlags.String("password", "", "`password` to encrypt the value")
-	encryptAlg   = encryptFlags.String("alg", props.EncryptDefault, "encryption `algorithm` to use (see props.Encrypt*)")
+	encryptFlags   = flag.NewFlagSet("encrypt", flag.ExitOnError)
+	encryptValue   = encryptFlags.String("value", "", "`plaintext` value to encrypt")
+	encryptPass    = encryptFlags.String("password", "", "`password` to encrypt the value")
+	encryptKeySrc  = encryptFlags.String("keysrc", "", "`source` of the key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	encryptAlg     = encryptFlags.String("alg", props.EncryptRecommended, "encryption `algorithm` to use, as a marker or id such as 3 for [enc:3] ("+strings.Join(props.Ciphers(), ", ")+")")
+	encryptKey     = encryptFlags.String("key", "", "property `key` to bind the value to for bound algorithms")
+	encryptContext = encryptFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
+	encryptKeyID   = encryptFlags.String("keyid", "", "`id` of the key to record in encrypted values for use with a props.Keyring")
+	encryptPubKey  = encryptFlags.String("pubkey", "", "X25519 public key `file` to encrypt with instead of a password (uses [enc:6] unless -alg is [enc:7])")
+	encryptGenKey  = encryptFlags.String("genkey", "", "generate an X25519 key pair, writing the private key to `file` and the public key to <file>.pub")
 )
 
 func init() {
@@ -23,32 +30,61 @@ func init() {
 }
 
 func encrypt() {
+	if *encryptGenKey != "" {
+		genX25519Key(*encryptGenKey)
+		return
+	}
 	if *encryptValue == "" {
 		fmt.Fprintf(flag.CommandLine.Output(), "the value parameter is required\n")
 		encryptFlags.Usage()
 		os.Exit(300)
 	}
-	if *encryptPass == "" {
+	if *encryptPubKey != "" {
+		*encryptPass, *encryptAlg = publicKey(*encryptPubKey, algID(*encryptAlg), encryptFlags, 301)
+	} else if keys := keySource(*encryptKeySrc, encryptFlags, 301); keys != nil {
+		*encryptKeyID, *encryptPass = primaryKey(keys, *encryptKeyID, encryptFlags, 301)
+	} else if *encryptPass == "" {
 		*encryptPass = readPassword("Password:", encryptFlags, 301)
 	}
-	if *encryptAlg != props.EncryptAESGCM {
-		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be an encryption algorithm id from props\n")
+	*encryptAlg = algID(*encryptAlg)
+	if !validAlg(*encryptAlg) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be one of %s\n", strings.Join(props.Ciphers(), ", "))
 		encryptFlags.Usage()
 		os.Exit(302)
 	}
 
-	switch len(*encryptPass) {
-	case 16, 24, 32:
-	default:
-		fmt.Fprintf(flag.CommandLine.Output(), "the password parameter must be 16, 24, or 32 bytes\n")
+	if !validPassword(*encryptAlg, *encryptPass) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the password parameter must be 16, 24, or 32 bytes for %s\n", *encryptAlg)
 		encryptFlags.Usage()
 		os.Exit(303)
 	}
 
-	enc, err := props.Encrypt(*encryptAlg, *encryptPass, *encryptValue)
+	enc, err := encryptWithID(*encryptAlg, *encryptKeyID, *encryptPass, *encryptValue, *encryptKey, splitContext(*encryptContext))
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "encrypt error: %v\n", err)
 		os.Exit(304)
 	}
 	fmt.Println(enc)
 }
+
+// genX25519Key writes a new X25519 private key to the file and its public key
+// to <file>.pub.
+func genX25519Key(file string) {
+	pub, priv, err := props.GenerateX25519Key()
+	if err == nil {
+		var f *os.File
+		f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = fmt.Fprintln(f, priv)
+			f.Close()
+		}
+	}
+	if err == nil {
+		err = os.WriteFile(file+".pub", []byte(pub+"\n"), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to generate key: %v\n", err)
+		os.Exit(305)
+	}
+	fmt.Printf("key written to %s and %s.pub\n", file, file)
+}
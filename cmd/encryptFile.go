@@ -12,11 +12,16 @@ import (
 )
 
 var (
-	encryptFileFlags  = flag.NewFlagSet("encryptFile", flag.ExitOnError)
-	encryptFilePath   = encryptFileFlags.String("path", "", "properties `file` to encrypt")
-	encryptFilePass   = encryptFileFlags.String("password", "", "`password` to encrypt the values")
-	encryptFileAlg    = encryptFileFlags.String("alg", props.EncryptDefault, "encryption `algorithm` to use (see props.Encrypt*)")
-	encryptFileOutput = encryptFileFlags.String("output", "", "output `file` to write results (default is input file)")
+	encryptFileFlags   = flag.NewFlagSet("encryptFile", flag.ExitOnError)
+	encryptFilePath    = encryptFileFlags.String("path", "", "properties `file` to encrypt")
+	encryptFilePass    = encryptFileFlags.String("password", "", "`password` to encrypt the values")
+	encryptFileKeySrc  = encryptFileFlags.String("keysrc", "", "`source` of the key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	encryptFileAlg     = encryptFileFlags.String("alg", props.EncryptRecommended, "encryption `algorithm` to use, as a marker or id such as 3 for [enc:3] ("+strings.Join(props.Ciphers(), ", ")+")")
+	encryptFileContext = encryptFileFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
+	encryptFileOutput  = encryptFileFlags.String("output", "", "output `file` to write results (default is input file)")
+	encryptFileKeyID   = encryptFileFlags.String("keyid", "", "`id` of the key to record in encrypted values for use with a props.Keyring")
+	encryptFilePubKey  = encryptFileFlags.String("pubkey", "", "X25519 public key `file` to encrypt with instead of a password (uses [enc:6] unless -alg is [enc:7])")
+	encryptFileEnv     = encryptFileFlags.Bool("envelope", false, "encrypt values with a new per-file data key stored in a header comment (files with a header always use it)")
 )
 
 func init() {
@@ -39,11 +44,17 @@ func encryptFile() {
 			os.Exit(401)
 		}
 	}
-	if *encryptFilePass == "" {
+	keys := keySource(*encryptFileKeySrc, encryptFileFlags, 402)
+	if *encryptFilePubKey != "" {
+		*encryptFilePass, *encryptFileAlg = publicKey(*encryptFilePubKey, algID(*encryptFileAlg), encryptFileFlags, 402)
+	} else if keys != nil {
+		*encryptFileKeyID, *encryptFilePass = primaryKey(keys, *encryptFileKeyID, encryptFileFlags, 402)
+	} else if *encryptFilePass == "" {
 		*encryptFilePass = readPassword("Password:", encryptFileFlags, 402)
 	}
-	if *encryptFileAlg != props.EncryptAESGCM {
-		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be an encryption algorithm id from props\n")
+	*encryptFileAlg = algID(*encryptFileAlg)
+	if !validAlg(*encryptFileAlg) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be one of %s\n", strings.Join(props.Ciphers(), ", "))
 		encryptFileFlags.Usage()
 		os.Exit(403)
 	}
@@ -51,6 +62,27 @@ func encryptFile() {
 		*encryptFileOutput = *encryptFilePath
 	}
 
+	master := masterKey(keys, *encryptFileKeyID, *encryptFilePass)
+	dk, err := fileDataKey(*encryptFilePath, master)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read data key: %v\n", err)
+		os.Exit(407)
+	}
+	var result bytes.Buffer
+	if dk == nil && *encryptFileEnv {
+		dk, err = props.NewDataKey()
+		if err == nil {
+			var header string
+			header, err = dk.Header(master, *encryptFileAlg)
+			result.WriteString(header)
+			result.WriteRune('\n')
+		}
+		if err != nil {
+			fmt.Fprintf(flag.CommandLine.Output(), "unable to create data key: %v\n", err)
+			os.Exit(407)
+		}
+	}
+
 	f, err := os.Open(*encryptFilePath)
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property file: %v\n", err)
@@ -59,7 +91,6 @@ func encryptFile() {
 	defer f.Close()
 
 	found := 0
-	var result bytes.Buffer
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -68,7 +99,13 @@ func encryptFile() {
 			i := strings.Index(line, props.EncryptNone)
 			val := line[i+len(props.EncryptNone):]
 			line := line[:i]
-			enc, err := props.Encrypt(*encryptFileAlg, *encryptFilePass, val)
+			var enc string
+			var err error
+			if dk != nil {
+				enc, err = dk.Encrypt(val, lineKey(line, i), splitContext(*encryptFileContext)...)
+			} else {
+				enc, err = encryptWithID(*encryptFileAlg, *encryptFileKeyID, *encryptFilePass, val, lineKey(line, i), splitContext(*encryptFileContext))
+			}
 			if err != nil {
 				fmt.Fprintf(flag.CommandLine.Output(), "unable to encrypt property: %v\n", err)
 				os.Exit(405)
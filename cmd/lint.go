@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rickar/props"
+)
+
+var (
+	lintFlags = flag.NewFlagSet("lint", flag.ExitOnError)
+	lintPath  = lintFlags.String("path", "", "properties, ini, or env `file` to check")
+)
+
+func init() {
+	lintFlags.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "lint: check a property file for duplicate keys\n")
+		lintFlags.PrintDefaults()
+	}
+}
+
+func lint() {
+	if *lintPath == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "the path parameter is required\n")
+		lintFlags.Usage()
+		os.Exit(800)
+	}
+
+	f, err := os.Open(*lintPath)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read input file: %v\n", err)
+		os.Exit(801)
+	}
+	defer f.Close()
+
+	p := props.NewProperties()
+	p.Duplicates = props.DuplicateError
+	switch filepath.Ext(*lintPath) {
+	case ".ini":
+		err = p.LoadINI(f)
+	case ".env":
+		err = p.LoadDotEnv(f)
+	default:
+		err = p.Load(f)
+	}
+
+	var dupErr *props.DuplicateKeyError
+	if errors.As(err, &dupErr) {
+		for _, key := range dupErr.Keys {
+			fmt.Printf("%s: duplicate key %s\n", *lintPath, key)
+		}
+		f.Close()
+		os.Exit(803)
+	} else if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to parse input file: %v\n", err)
+		f.Close()
+		os.Exit(802)
+	}
+}
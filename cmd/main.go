@@ -1,16 +1,21 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/rickar/props"
 	"golang.org/x/term"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "a command is required (decrypt, decryptFile, encrypt, encryptFile, recrypt, recryptFile)\n")
+		fmt.Fprintf(os.Stderr, "a command is required (decrypt, decryptFile, encrypt, encryptFile, lint, recrypt, recryptFile, render, sign, verify)\n")
 		os.Exit(1)
 	}
 
@@ -27,14 +32,26 @@ func main() {
 	case "encryptFile":
 		encryptFileFlags.Parse(os.Args[2:])
 		encryptFile()
+	case "lint":
+		lintFlags.Parse(os.Args[2:])
+		lint()
 	case "recrypt":
 		recryptFlags.Parse(os.Args[2:])
 		recrypt()
 	case "recryptFile":
 		recryptFileFlags.Parse(os.Args[2:])
 		recryptFile()
+	case "render":
+		renderFlags.Parse(os.Args[2:])
+		render()
+	case "sign":
+		signFlags.Parse(os.Args[2:])
+		sign()
+	case "verify":
+		verifyFlags.Parse(os.Args[2:])
+		verify()
 	default:
-		fmt.Fprintf(os.Stderr, "a command is required (decrypt, decryptFile, encrypt, encryptFile, recrypt, recryptFile)\n")
+		fmt.Fprintf(os.Stderr, "a command is required (decrypt, decryptFile, encrypt, encryptFile, lint, recrypt, recryptFile, render, sign, verify)\n")
 		os.Exit(2)
 	}
 }
@@ -57,3 +74,223 @@ func readPassword(prompt string, flags *flag.FlagSet, exitCode int) string {
 	}
 	return pass
 }
+
+// algID converts a short algorithm id such as "3" into its marker form
+// "[enc:3]" so that brackets do not need to be quoted in a shell.
+func algID(alg string) string {
+	if alg != "" && !strings.HasPrefix(alg, "[") {
+		return "[enc:" + alg + "]"
+	}
+	return alg
+}
+
+// validAlg determines whether alg is a registered algorithm that can be used
+// to encrypt new values.
+func validAlg(alg string) bool {
+	for _, id := range props.Ciphers() {
+		if id == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// validPassword determines whether the password can be used with alg. AES-GCM
+// uses the password directly as the key so it must be a valid key size.
+func validPassword(alg, pass string) bool {
+	if alg != props.EncryptAESGCM && alg != props.EncryptAESGCMKeyBound {
+		return true
+	}
+	switch len(pass) {
+	case 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+// encryptedIndex returns the position of the first encrypted value in the line
+// or -1 if there is none. Values marked with props.EncryptNone are skipped.
+func encryptedIndex(line string) int {
+	i := 0
+	for {
+		j := strings.Index(line[i:], "[enc:")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if !strings.HasPrefix(line[i:], props.EncryptNone) {
+			return i
+		}
+		i += len(props.EncryptNone)
+	}
+}
+
+// lineKey returns the property key for a line in a property file where the
+// value starts at position i, or an empty string if there is no key.
+func lineKey(line string, i int) string {
+	p, err := props.Read(strings.NewReader(line[:i]))
+	if err != nil {
+		return ""
+	}
+	names := p.Names()
+	if len(names) != 1 {
+		return ""
+	}
+	return names[0]
+}
+
+// splitContext converts a comma separated list of binding context values into
+// a slice.
+func splitContext(context string) []string {
+	if context == "" {
+		return nil
+	}
+	return strings.Split(context, ",")
+}
+
+// keySource returns the key provider described by src, or nil if src is
+// empty. The forms are env:NAME, file:PATH, fd:N, and an http or https URL for
+// a key service. The PROPS_KEY_TOKEN environment variable provides the bearer
+// token for a key service.
+func keySource(src string, flags *flag.FlagSet, exitCode int) props.KeyProvider {
+	kind, val, _ := strings.Cut(src, ":")
+	switch {
+	case src == "":
+		return nil
+	case kind == "env" && val != "":
+		return &props.EnvKey{Name: val}
+	case kind == "file" && val != "":
+		return &props.FileKey{Path: val}
+	case kind == "fd":
+		fd, err := strconv.ParseUint(val, 10, 32)
+		if err == nil {
+			return &props.FDKey{FD: uintptr(fd)}
+		}
+	case kind == "http" || kind == "https":
+		return &props.HTTPKey{URL: src, Token: os.Getenv("PROPS_KEY_TOKEN")}
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "the key source must be env:NAME, file:PATH, fd:N, or an http(s) URL\n")
+	flags.Usage()
+	os.Exit(exitCode)
+	return nil
+}
+
+// primaryKey returns the ID and password of the primary key from the provider.
+// The keyID is returned instead of the provider's ID if it is not empty.
+func primaryKey(p props.KeyProvider, keyID string, flags *flag.FlagSet, exitCode int) (string, string) {
+	id, pass, err := p.PrimaryKey()
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to get key: %v\n", err)
+		flags.Usage()
+		os.Exit(exitCode)
+	}
+	if keyID != "" {
+		id = keyID
+	}
+	return id, pass
+}
+
+// publicKey reads an X25519 public key file and returns the key and the
+// algorithm to use with it. The default algorithm is replaced with
+// props.EncryptX25519.
+func publicKey(file, alg string, flags *flag.FlagSet, exitCode int) (string, string) {
+	if alg == props.EncryptRecommended {
+		alg = props.EncryptX25519
+	}
+	if alg != props.EncryptX25519 && alg != props.EncryptX25519Bound {
+		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be %s or %s with a public key\n", props.EncryptX25519, props.EncryptX25519Bound)
+		flags.Usage()
+		os.Exit(exitCode)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read public key: %v\n", err)
+		flags.Usage()
+		os.Exit(exitCode)
+	}
+	return strings.TrimSpace(string(data)), alg
+}
+
+// decryptWith decrypts the value bound to the property key and context using
+// the key provider or, if it is nil, the password.
+func decryptWith(p props.KeyProvider, password, val, key string, context []string) (string, error) {
+	if p != nil {
+		return props.DecryptBoundWith(p, val, key, context...)
+	}
+	return props.DecryptBound(password, val, key, context...)
+}
+
+// passwordKey provides a password given on the command line as a master key.
+type passwordKey struct {
+	id       string
+	password string
+}
+
+func (p passwordKey) PrimaryKey() (string, string, error) {
+	return p.id, p.password, nil
+}
+
+func (p passwordKey) Key(id string) (string, error) {
+	return p.password, nil
+}
+
+// masterKey returns the key provider or, if it is nil, the password and key ID
+// as a provider.
+func masterKey(p props.KeyProvider, keyID, password string) props.KeyProvider {
+	if p != nil {
+		return p
+	}
+	return passwordKey{id: keyID, password: password}
+}
+
+// fileDataKey returns the data key from the header of the property file or nil
+// if the file does not have one.
+func fileDataKey(path string, master props.KeyProvider) (*props.DataKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return props.FindDataKey(master, f)
+}
+
+// signer returns an Ed25519 signer if keyFile is set or an HMAC signer using
+// the key from the key source or password. The key file holds a base64 encoded
+// private or public key.
+func signer(keyFile, keySrc, password string, flags *flag.FlagSet, exitCode int) props.Signer {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err == nil {
+			data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		}
+		if err == nil {
+			switch len(data) {
+			case ed25519.PrivateKeySize:
+				return &props.Ed25519Signer{PrivateKey: data}
+			case ed25519.PublicKeySize:
+				return &props.Ed25519Signer{PublicKey: data}
+			}
+			err = fmt.Errorf("invalid key size %d", len(data))
+		}
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read key file: %v\n", err)
+		flags.Usage()
+		os.Exit(exitCode)
+	}
+
+	if keys := keySource(keySrc, flags, exitCode); keys != nil {
+		_, password = primaryKey(keys, "", flags, exitCode)
+	} else if password == "" {
+		password = readPassword("Password:", flags, exitCode)
+	}
+	return &props.HMACSigner{Key: []byte(password)}
+}
+
+// encryptWithID encrypts the value bound to the property key and context. If a
+// key ID is provided, it is added to the value's marker.
+func encryptWithID(alg, keyID, password, value, key string, context []string) (string, error) {
+	if keyID == "" {
+		return props.EncryptBound(alg, password, value, key, context...)
+	}
+	kr := &props.Keyring{Primary: keyID, Keys: map[string]string{keyID: password}}
+	return kr.EncryptBound(alg, value, key, context...)
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rickar/props"
 )
@@ -13,7 +14,12 @@ var (
 	recryptValue   = recryptFlags.String("value", "", "`encrypted` value to re-encrypt")
 	recryptPass    = recryptFlags.String("newpass", "", "new `password` to re-encrypt the value")
 	recryptOldPass = recryptFlags.String("oldpass", "", "old `password` to decrypt the value")
-	recryptAlg     = recryptFlags.String("alg", props.EncryptDefault, "encryption `algorithm` to use (see props.Encrypt*)")
+	recryptOldSrc  = recryptFlags.String("oldkeysrc", "", "`source` of the old key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	recryptNewSrc  = recryptFlags.String("newkeysrc", "", "`source` of the new key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	recryptAlg     = recryptFlags.String("alg", props.EncryptRecommended, "encryption `algorithm` to use, as a marker or id such as 3 for [enc:3] ("+strings.Join(props.Ciphers(), ", ")+")")
+	recryptKey     = recryptFlags.String("key", "", "property `key` the value is bound to for bound algorithms")
+	recryptContext = recryptFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
+	recryptKeyID   = recryptFlags.String("keyid", "", "`id` of the key to record in encrypted values for use with a props.Keyring")
 )
 
 func init() {
@@ -29,36 +35,36 @@ func recrypt() {
 		recryptFlags.Usage()
 		os.Exit(500)
 	}
-	if *recryptOldPass == "" {
+	oldKeys := keySource(*recryptOldSrc, recryptFlags, 501)
+	if oldKeys == nil && *recryptOldPass == "" {
 		*recryptOldPass = readPassword("Old Password:", recryptFlags, 501)
 	}
-	if *recryptPass == "" {
+	if newKeys := keySource(*recryptNewSrc, recryptFlags, 502); newKeys != nil {
+		*recryptKeyID, *recryptPass = primaryKey(newKeys, *recryptKeyID, recryptFlags, 502)
+	} else if *recryptPass == "" {
 		*recryptPass = readPassword("New Password:", recryptFlags, 502)
 	}
-	if *recryptAlg != props.EncryptAESGCM {
-		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be an encryption algorithm id from props\n")
+	*recryptAlg = algID(*recryptAlg)
+	if !validAlg(*recryptAlg) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be one of %s\n", strings.Join(props.Ciphers(), ", "))
 		recryptFlags.Usage()
 		os.Exit(503)
 	}
 
-	switch len(*recryptPass) {
-	case 16, 24, 32:
-	default:
-		fmt.Fprintf(flag.CommandLine.Output(), "the newpass parameter must be 16, 24, or 32 bytes\n")
+	if !validPassword(*recryptAlg, *recryptPass) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the newpass parameter must be 16, 24, or 32 bytes for %s\n", *recryptAlg)
 		recryptFlags.Usage()
 		os.Exit(504)
 	}
 
-	p := props.NewProperties()
-	p.Set("val", *recryptValue)
-	c := props.Configuration{Props: p}
-	dec, err := c.Decrypt(*recryptOldPass, "val", "")
+	context := splitContext(*recryptContext)
+	dec, err := decryptWith(oldKeys, *recryptOldPass, *recryptValue, *recryptKey, context)
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "decrypt error: %v\n", err)
 		os.Exit(505)
 	}
 
-	enc, err := props.Encrypt(*recryptAlg, *recryptPass, dec)
+	enc, err := encryptWithID(*recryptAlg, *recryptKeyID, *recryptPass, dec, *recryptKey, context)
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "encrypt error: %v\n", err)
 		os.Exit(506)
@@ -16,8 +16,12 @@ var (
 	recryptFilePath    = recryptFileFlags.String("path", "", "properties `file` to re-encrypt")
 	recryptFilePass    = recryptFileFlags.String("newpass", "", "new `password` to re-encrypt the values")
 	recryptFileOldPass = recryptFileFlags.String("oldpass", "", "old `password` to decrypt the values")
-	recryptFileAlg     = recryptFileFlags.String("alg", props.EncryptDefault, "encryption `algorithm` to use (see props.Encrypt*)")
+	recryptFileOldSrc  = recryptFileFlags.String("oldkeysrc", "", "`source` of the old key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	recryptFileNewSrc  = recryptFileFlags.String("newkeysrc", "", "`source` of the new key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	recryptFileAlg     = recryptFileFlags.String("alg", props.EncryptRecommended, "encryption `algorithm` to use, as a marker or id such as 3 for [enc:3] ("+strings.Join(props.Ciphers(), ", ")+")")
 	recryptFileOutput  = recryptFileFlags.String("output", "", "output `file` to write results (default is input file)")
+	recryptFileContext = recryptFileFlags.String("context", "", "comma separated `list` of context values, such as a file or profile name, that bound values are bound to")
+	recryptFileKeyID   = recryptFileFlags.String("keyid", "", "`id` of the key to record in encrypted values for use with a props.Keyring")
 )
 
 func init() {
@@ -40,21 +44,40 @@ func recryptFile() {
 			os.Exit(601)
 		}
 	}
-	if *recryptFileAlg != props.EncryptAESGCM {
-		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be an encryption algorithm id from props\n")
+	*recryptFileAlg = algID(*recryptFileAlg)
+	if !validAlg(*recryptFileAlg) {
+		fmt.Fprintf(flag.CommandLine.Output(), "the alg parameter must be one of %s\n", strings.Join(props.Ciphers(), ", "))
 		recryptFileFlags.Usage()
 		os.Exit(602)
 	}
-	if *recryptFileOldPass == "" {
+	oldKeys := keySource(*recryptFileOldSrc, recryptFileFlags, 603)
+	if oldKeys == nil && *recryptFileOldPass == "" {
 		*recryptFileOldPass = readPassword("Old Password:", recryptFileFlags, 603)
 	}
-	if *recryptFilePass == "" {
+	newKeys := keySource(*recryptFileNewSrc, recryptFileFlags, 604)
+	if newKeys != nil {
+		*recryptFileKeyID, *recryptFilePass = primaryKey(newKeys, *recryptFileKeyID, recryptFileFlags, 604)
+	} else if *recryptFilePass == "" {
 		*recryptFilePass = readPassword("New Password:", recryptFileFlags, 604)
 	}
 	if *recryptFileOutput == "" {
 		*recryptFileOutput = *recryptFilePath
 	}
 
+	dk, err := fileDataKey(*recryptFilePath, masterKey(oldKeys, "", *recryptFileOldPass))
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read data key: %v\n", err)
+		os.Exit(608)
+	}
+	var header string
+	if dk != nil {
+		header, err = dk.Header(masterKey(newKeys, *recryptFileKeyID, *recryptFilePass), *recryptFileAlg)
+		if err != nil {
+			fmt.Fprintf(flag.CommandLine.Output(), "unable to wrap data key: %v\n", err)
+			os.Exit(608)
+		}
+	}
+
 	f, err := os.Open(*recryptFilePath)
 	if err != nil {
 		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property file: %v\n", err)
@@ -67,18 +90,21 @@ func recryptFile() {
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.Contains(line, props.EncryptAESGCM) {
+		if dk != nil && props.IsDataKeyHeader(line) {
+			// only the header changes; values encrypted with the data key are kept
+			result.WriteString(header)
+			result.WriteRune('\n')
+		} else if i := encryptedIndex(line); i >= 0 && (dk == nil || props.KeyID(line[i:]) != dk.ID) {
 			found++
-			i := strings.Index(line, props.EncryptAESGCM)
 			val := line[i:]
 			line := line[:i]
-			dec, err := props.Decrypt(*recryptFileOldPass, val)
+			dec, err := decryptWith(oldKeys, *recryptFileOldPass, val, lineKey(line, i), splitContext(*recryptFileContext))
 			if err != nil {
 				fmt.Fprintf(flag.CommandLine.Output(), "unable to decrypt property: %v\n", err)
 				os.Exit(100)
 			}
 
-			enc, err := props.Encrypt(*recryptFileAlg, *recryptFilePass, dec)
+			enc, err := encryptWithID(*recryptFileAlg, *recryptFileKeyID, *recryptFilePass, dec, lineKey(line, i), splitContext(*recryptFileContext))
 			if err != nil {
 				fmt.Fprintf(flag.CommandLine.Output(), "unable to encrypt property: %v\n", err)
 				os.Exit(606)
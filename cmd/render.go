@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/rickar/props"
+)
+
+var (
+	renderFlags    = flag.NewFlagSet("render", flag.ExitOnError)
+	renderTemplate = renderFlags.String("template", "", "text/template `file` to render")
+	renderDir      = renderFlags.String("dir", ".", "`directory` containing the property files")
+	renderPrefix   = renderFlags.String("prefix", "", "property file `prefix` (as in <prefix>-<profile>.properties)")
+	renderProfiles = renderFlags.String("profiles", "", "comma separated `list` of profiles in priority order")
+	renderOutput   = renderFlags.String("output", "", "output `file` to write results (default is stdout)")
+)
+
+func init() {
+	renderFlags.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "render: render a text/template using property values\n")
+		renderFlags.PrintDefaults()
+	}
+}
+
+func render() {
+	if *renderTemplate == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "the template parameter is required\n")
+		renderFlags.Usage()
+		os.Exit(700)
+	}
+	if *renderPrefix == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "the prefix parameter is required\n")
+		renderFlags.Usage()
+		os.Exit(701)
+	}
+
+	var profiles []string
+	if *renderProfiles != "" {
+		profiles = strings.Split(*renderProfiles, ",")
+	}
+	conf, err := props.NewConfiguration(os.DirFS(*renderDir).(fs.StatFS), *renderPrefix, profiles...)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property files: %v\n", err)
+		os.Exit(702)
+	}
+
+	text, err := os.ReadFile(*renderTemplate)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read template file: %v\n", err)
+		os.Exit(703)
+	}
+
+	out := os.Stdout
+	if *renderOutput != "" {
+		out, err = os.Create(*renderOutput)
+		if err != nil {
+			fmt.Fprintf(flag.CommandLine.Output(), "unable to write output: %v\n", err)
+			os.Exit(704)
+		}
+		defer out.Close()
+	}
+
+	err = props.RenderTemplate(out, *renderTemplate, string(text), conf)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "render error: %v\n", err)
+		os.Exit(705)
+	}
+}
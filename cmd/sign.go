@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rickar/props"
+)
+
+var (
+	signFlags   = flag.NewFlagSet("sign", flag.ExitOnError)
+	signPath    = signFlags.String("path", "", "property `file` to sign")
+	signPass    = signFlags.String("password", "", "HMAC `key` to sign the file with (when -key is not used)")
+	signKeySrc  = signFlags.String("keysrc", "", "`source` of the HMAC key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	signKey     = signFlags.String("key", "", "Ed25519 private key `file` to sign the file with")
+	signGenKey  = signFlags.Bool("genkey", false, "generate a new Ed25519 key pair in the -key file and <file>.pub before signing")
+	signSidecar = signFlags.Bool("sidecar", false, "write the signature to <file>.sig instead of a trailer comment (required for json)")
+	signOutput  = signFlags.String("output", "", "output `file` to write results (default is input file)")
+)
+
+func init() {
+	signFlags.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "sign: add a signature to a property file to detect changes\n")
+		signFlags.PrintDefaults()
+	}
+}
+
+func sign() {
+	if *signGenKey {
+		if *signKey == "" {
+			fmt.Fprintf(flag.CommandLine.Output(), "the key parameter is required to generate a key\n")
+			signFlags.Usage()
+			os.Exit(905)
+		}
+		genKey(*signKey)
+		if *signPath == "" {
+			return
+		}
+	}
+	if *signPath == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "the path parameter is required\n")
+		signFlags.Usage()
+		os.Exit(900)
+	}
+	if *signOutput == "" {
+		*signOutput = *signPath
+	}
+	s := signer(*signKey, *signKeySrc, *signPass, signFlags, 901)
+
+	data, err := os.ReadFile(*signPath)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property file: %v\n", err)
+		os.Exit(902)
+	}
+
+	if *signSidecar {
+		var line string
+		line, err = props.FileSignature(s, *signPath, data)
+		if err == nil {
+			err = os.WriteFile(*signOutput+".sig", []byte(line+"\n"), 0o644)
+		}
+	} else {
+		data, err = props.SignFile(s, *signPath, data)
+		if err == nil {
+			err = os.WriteFile(*signOutput, data, 0o644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "sign error: %v\n", err)
+		os.Exit(903)
+	}
+	fmt.Printf("%s signed\n", *signPath)
+}
+
+// genKey writes a new Ed25519 private key to the file and its public key to
+// <file>.pub, both base64 encoded.
+func genKey(file string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err == nil {
+		var f *os.File
+		f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = fmt.Fprintln(f, base64.StdEncoding.EncodeToString(priv))
+			f.Close()
+		}
+	}
+	if err == nil {
+		err = os.WriteFile(file+".pub", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to generate key: %v\n", err)
+		os.Exit(904)
+	}
+	fmt.Printf("key written to %s and %s.pub\n", file, file)
+}
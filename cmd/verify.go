@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rickar/props"
+)
+
+var (
+	verifyFlags  = flag.NewFlagSet("verify", flag.ExitOnError)
+	verifyPath   = verifyFlags.String("path", "", "property `file` to verify")
+	verifyPass   = verifyFlags.String("password", "", "HMAC `key` the file was signed with (when -key is not used)")
+	verifyKeySrc = verifyFlags.String("keysrc", "", "`source` of the HMAC key instead of a password: env:NAME, file:PATH, fd:N, or an http(s) key service URL")
+	verifyKey    = verifyFlags.String("key", "", "Ed25519 public key `file` to verify the file with")
+)
+
+func init() {
+	verifyFlags.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "verify: check the signature of a property file\n")
+		verifyFlags.PrintDefaults()
+	}
+}
+
+func verify() {
+	if *verifyPath == "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "the path parameter is required\n")
+		verifyFlags.Usage()
+		os.Exit(1000)
+	}
+	s := signer(*verifyKey, *verifyKeySrc, *verifyPass, verifyFlags, 1001)
+
+	data, err := os.ReadFile(*verifyPath)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "unable to read property file: %v\n", err)
+		os.Exit(1002)
+	}
+	sidecar, _ := os.ReadFile(*verifyPath + ".sig")
+
+	_, err = props.VerifyFile(s, *verifyPath, data, sidecar)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1003)
+	}
+	fmt.Printf("%s: signature ok\n", *verifyPath)
+}
@@ -2,24 +2,59 @@
 
 package props
 
+import (
+	"fmt"
+	"sync"
+)
+
 // Combined provides property value lookups across multiple sources.
+//
+// Sources may be given names with AddFirst, AddLast, AddBefore, and AddAfter
+// so that they can later be found, removed, or replaced. These methods, along
+// with the override methods and all lookups, are safe for concurrent use.
+// Changing the Sources slice directly is not safe while other goroutines are
+// using the Combined.
+//
+// Values set with SetOverride take priority over all sources. For example, to
+// pin a value in a test:
+//
+//	c.SetOverride("feature.enabled", "true")
+//	defer c.RemoveOverride("feature.enabled")
 type Combined struct {
 	// The property sources to use for lookup in priority order. The first
 	// source to have a value for a property will be used.
 	Sources []PropertyGetter
+
+	mu sync.RWMutex
+
+	// names holds the layer name for each source; it may be shorter than
+	// Sources if sources were added directly
+	names []string
+
+	// overrides holds values that take priority over all sources
+	overrides map[string]string
+
+	// version is incremented for changes to the layers and overrides
+	version uint64
 }
 
-// Ensure that Combined implements PropertyGetter
-var _ PropertyGetter = &Combined{}
+// Ensure that Combined implements PropertyGetter, MultiGetter, and Versioned
+var (
+	_ PropertyGetter = &Combined{}
+	_ MultiGetter    = &Combined{}
+	_ Versioned      = &Combined{}
+)
 
-// Get retrieves the value of a property from the source list. If the source
-// list is empty or none of the sources has the property, an empty string will
-// be returned. The bool return value indicates whether the property was found.
+// Get retrieves the value of a property from the overrides or the source
+// list. If the source list is empty or none of the sources has the property,
+// an empty string will be returned. The bool return value indicates whether
+// the property was found.
 func (c *Combined) Get(key string) (string, bool) {
-	if c.Sources == nil {
-		return "", false
+	sources, val, ok := c.snapshot(key)
+	if ok {
+		return val, true
 	}
-	for _, l := range c.Sources {
+	for _, l := range sources {
 		val, ok := l.Get(key)
 		if ok {
 			return val, true
@@ -40,10 +75,37 @@ func (c *Combined) GetDefault(key string, defVal string) string {
 	}
 }
 
+// GetAll retrieves every value of a property from the overrides and all
+// sources in priority order. Sources that implement MultiGetter may provide
+// more than one value; other sources provide the value from Get. If none of
+// the sources has the property, nil will be returned.
+func (c *Combined) GetAll(key string) []string {
+	sources, val, ok := c.snapshot(key)
+	var result []string
+	if ok {
+		result = append(result, val)
+	}
+	for _, l := range sources {
+		if m, ok := l.(MultiGetter); ok {
+			result = append(result, m.GetAll(key)...)
+		} else if val, ok := l.Get(key); ok {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
 // Names returns the unique names of all properties that have been set.
 func (c *Combined) Names() []string {
-	vals := make(map[string]struct{})
-	for _, l := range c.Sources {
+	c.mu.RLock()
+	sources := c.Sources
+	vals := make(map[string]struct{}, len(c.overrides))
+	for k := range c.overrides {
+		vals[k] = struct{}{}
+	}
+	c.mu.RUnlock()
+
+	for _, l := range sources {
 		for _, v := range l.Names() {
 			vals[v] = struct{}{}
 		}
@@ -55,3 +117,219 @@ func (c *Combined) Names() []string {
 	}
 	return result
 }
+
+// Version returns a number that changes whenever the layers or overrides
+// change or the version of a source that implements Versioned changes.
+// Changes to sources that do not implement Versioned are not reflected.
+func (c *Combined) Version() uint64 {
+	c.mu.RLock()
+	sources := c.Sources
+	ver := c.version
+	c.mu.RUnlock()
+
+	return ver + sourceVersions(sources...)
+}
+
+// SetOverride sets a value that takes priority over all sources.
+func (c *Combined) SetOverride(key, val string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overrides == nil {
+		c.overrides = make(map[string]string)
+	}
+	c.overrides[key] = val
+	c.version++
+}
+
+// RemoveOverride removes a value set with SetOverride so that the value from
+// the sources is used again.
+func (c *Combined) RemoveOverride(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.overrides, key)
+	c.version++
+}
+
+// ClearOverrides removes all values set with SetOverride.
+func (c *Combined) ClearOverrides() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overrides = nil
+	c.version++
+}
+
+// AddFirst adds a named source with a higher priority than all other sources.
+// An error will be returned if the name is empty or already in use.
+func (c *Combined) AddFirst(name string, src PropertyGetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.insert(0, name, src)
+}
+
+// AddLast adds a named source with a lower priority than all other sources.
+// An error will be returned if the name is empty or already in use.
+func (c *Combined) AddLast(name string, src PropertyGetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.insert(len(c.Sources), name, src)
+}
+
+// AddBefore adds a named source with a priority just higher than the source
+// named by before. An error will be returned if before does not exist or if
+// the name is empty or already in use.
+func (c *Combined) AddBefore(before, name string, src PropertyGetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(before)
+	if i < 0 {
+		return fmt.Errorf("unknown layer %s", before)
+	}
+	return c.insert(i, name, src)
+}
+
+// AddAfter adds a named source with a priority just lower than the source
+// named by after. An error will be returned if after does not exist or if the
+// name is empty or already in use.
+func (c *Combined) AddAfter(after, name string, src PropertyGetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(after)
+	if i < 0 {
+		return fmt.Errorf("unknown layer %s", after)
+	}
+	return c.insert(i+1, name, src)
+}
+
+// Remove removes the named source. An error will be returned if the source
+// does not exist.
+func (c *Combined) Remove(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(name)
+	if i < 0 {
+		return fmt.Errorf("unknown layer %s", name)
+	}
+
+	names := c.layerNames()
+	sources := make([]PropertyGetter, 0, len(c.Sources)-1)
+	sources = append(sources, c.Sources[:i]...)
+	sources = append(sources, c.Sources[i+1:]...)
+	c.names = append(names[:i:i], names[i+1:]...)
+
+	// keep the version increasing after the source's version is removed
+	c.version += sourceVersions(c.Sources[i]) + 1
+	c.Sources = sources
+	return nil
+}
+
+// Replace changes the named source to a new source with the same priority.
+// An error will be returned if the source does not exist.
+func (c *Combined) Replace(name string, src PropertyGetter) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(name)
+	if i < 0 {
+		return fmt.Errorf("unknown layer %s", name)
+	}
+
+	sources := make([]PropertyGetter, len(c.Sources))
+	copy(sources, c.Sources)
+	sources[i] = src
+
+	c.version += sourceVersions(c.Sources[i]) + 1
+	c.Sources = sources
+	return nil
+}
+
+// Layer retrieves the named source. The bool return value indicates whether
+// the source was found.
+func (c *Combined) Layer(name string) (PropertyGetter, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i := c.index(name)
+	if i < 0 {
+		return nil, false
+	}
+	return c.Sources[i], true
+}
+
+// Layers returns the names of all sources in priority order. Sources that
+// were added directly to Sources have an empty name.
+func (c *Combined) Layers() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.layerNames()...)
+}
+
+// snapshot returns the current sources along with the override for the key.
+// Sources are replaced rather than modified by the layer methods so the
+// returned slice may be used without holding the lock.
+func (c *Combined) snapshot(key string) ([]PropertyGetter, string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	val, ok := c.overrides[key]
+	return c.Sources, val, ok
+}
+
+// insert adds a named source at position i. The lock must be held.
+func (c *Combined) insert(i int, name string, src PropertyGetter) error {
+	if name == "" {
+		return fmt.Errorf("layer name is required")
+	}
+	if c.index(name) >= 0 {
+		return fmt.Errorf("duplicate layer %s", name)
+	}
+
+	names := c.layerNames()
+	sources := make([]PropertyGetter, 0, len(c.Sources)+1)
+	sources = append(sources, c.Sources[:i]...)
+	sources = append(sources, src)
+	sources = append(sources, c.Sources[i:]...)
+
+	newNames := make([]string, 0, len(sources))
+	newNames = append(newNames, names[:i]...)
+	newNames = append(newNames, name)
+	newNames = append(newNames, names[i:]...)
+
+	c.names = newNames
+	c.Sources = sources
+	c.version++
+	return nil
+}
+
+// index returns the position of the named source or -1 if it does not exist.
+// The lock must be held.
+func (c *Combined) index(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, n := range c.layerNames() {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// layerNames returns the name of each source, filling in empty names for
+// sources that were added directly. The lock must be held.
+func (c *Combined) layerNames() []string {
+	if len(c.names) >= len(c.Sources) {
+		return c.names[:len(c.Sources)]
+	}
+	names := make([]string, len(c.Sources))
+	copy(names, c.names)
+	return names
+}
+
+// sourceVersions returns the sum of the versions of the sources that
+// implement Versioned.
+func sourceVersions(sources ...PropertyGetter) uint64 {
+	var ver uint64
+	for _, l := range sources {
+		if v, ok := l.(Versioned); ok {
+			ver += v.Version()
+		}
+	}
+	return ver
+}
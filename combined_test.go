@@ -5,6 +5,8 @@ package props
 import (
 	"reflect"
 	"sort"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -74,3 +76,196 @@ func TestCombinedNames(t *testing.T) {
 		t.Errorf("want: %v; got %v", want, got)
 	}
 }
+
+func TestCombinedGetAll(t *testing.T) {
+	p1 := NewProperties()
+	p1.Add("key", "a")
+	p1.Add("key", "b")
+	p2 := &Environment{Vars: map[string]string{"key": "c"}}
+	p3 := NewProperties()
+	p3.Set("key", "d")
+	p3.Set("other", "e")
+	c := &Combined{Sources: []PropertyGetter{p1, p2, p3}}
+
+	if got := c.GetAll("key"); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("want: [a b c d]; got: %v", got)
+	}
+	if got := c.GetAll("other"); !reflect.DeepEqual(got, []string{"e"}) {
+		t.Errorf("want: [e]; got: %v", got)
+	}
+	if got := c.GetAll("missing"); got != nil {
+		t.Errorf("want: nil; got: %v", got)
+	}
+}
+
+func TestCombinedLayers(t *testing.T) {
+	props := func(val string) *Properties {
+		p := NewProperties()
+		p.Set("key", val)
+		p.Set(val, val)
+		return p
+	}
+
+	c := &Combined{Sources: []PropertyGetter{props("direct")}}
+	if err := c.AddLast("env", props("env")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if err := c.AddFirst("args", props("args")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if err := c.AddBefore("env", "secrets", props("secrets")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if err := c.AddAfter("env", "file", props("file")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	want := []string{"args", "", "secrets", "env", "file"}
+	if got := c.Layers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+	if got := c.GetAll("key"); !reflect.DeepEqual(got, []string{"args", "direct", "secrets", "env", "file"}) {
+		t.Errorf("want: values in layer order; got: %v", got)
+	}
+
+	if err := c.Remove("args"); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := c.Get("key"); v != "direct" {
+		t.Errorf("want: direct; got: %s", v)
+	}
+	if err := c.Replace("env", props("env2")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := c.Get("env2"); v != "env2" {
+		t.Errorf("want: env2; got: %s", v)
+	}
+	if _, ok := c.Get("env"); ok {
+		t.Errorf("want: env removed; got: found")
+	}
+	if l, ok := c.Layer("secrets"); !ok || l.GetDefault("key", "") != "secrets" {
+		t.Errorf("want: secrets layer; got: %v, %t", l, ok)
+	}
+	if _, ok := c.Layer("args"); ok {
+		t.Errorf("want: no args layer; got: found")
+	}
+
+	want = []string{"", "secrets", "env", "file"}
+	if got := c.Layers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	errTests := []error{
+		c.AddFirst("", props("x")),
+		c.AddLast("env", props("x")),
+		c.AddBefore("missing", "x", props("x")),
+		c.AddAfter("missing", "x", props("x")),
+		c.AddBefore("", "x", props("x")),
+		c.Remove("missing"),
+		c.Replace("missing", props("x")),
+	}
+	for i, err := range errTests {
+		if err == nil {
+			t.Errorf("%d want err; got none", i)
+		}
+	}
+}
+
+func TestCombinedOverrides(t *testing.T) {
+	p := NewProperties()
+	p.Set("key", "source")
+	c := &Combined{Sources: []PropertyGetter{p}}
+	e := NewExpander(c)
+	e.Cache = true
+
+	if v, _ := e.Get("key"); v != "source" {
+		t.Errorf("want: source; got: %s", v)
+	}
+
+	c.SetOverride("key", "override")
+	c.SetOverride("extra", "${key}")
+	if v, _ := e.Get("key"); v != "override" {
+		t.Errorf("want: override; got: %s", v)
+	}
+	if v, _ := e.Get("extra"); v != "override" {
+		t.Errorf("want: override; got: %s", v)
+	}
+	if got := c.GetAll("key"); !reflect.DeepEqual(got, []string{"override", "source"}) {
+		t.Errorf("want: [override source]; got: %v", got)
+	}
+	names := c.Names()
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"extra", "key"}) {
+		t.Errorf("want: [extra key]; got: %v", names)
+	}
+
+	c.RemoveOverride("key")
+	if v, _ := e.Get("key"); v != "source" {
+		t.Errorf("want: source; got: %s", v)
+	}
+	c.ClearOverrides()
+	if _, ok := e.Get("extra"); ok {
+		t.Errorf("want: extra removed; got: found")
+	}
+}
+
+func TestCombinedVersion(t *testing.T) {
+	p1 := NewProperties()
+	p1.Set("a", "1")
+	p1.Set("a", "2")
+	p1.Set("a", "3")
+	c := &Combined{}
+	c.AddLast("p1", p1)
+
+	seen := map[uint64]struct{}{c.Version(): {}}
+	check := func(step string) {
+		v := c.Version()
+		if _, ok := seen[v]; ok {
+			t.Errorf("%s want: new version; got: %d", step, v)
+		}
+		seen[v] = struct{}{}
+	}
+
+	c.Remove("p1")
+	check("remove")
+	c.AddLast("p2", NewProperties())
+	check("add")
+	c.Replace("p2", NewProperties())
+	check("replace")
+	c.SetOverride("a", "b")
+	check("override")
+}
+
+func TestCombinedConcurrent(t *testing.T) {
+	c := &Combined{}
+	c.AddLast("base", NewProperties())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			name := "layer" + strconv.Itoa(i)
+			for j := 0; j < 100; j++ {
+				c.AddFirst(name, NewProperties())
+				c.SetOverride(name, "x")
+				c.Remove(name)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Get("key")
+				c.GetAll("key")
+				c.Names()
+				c.Layers()
+				c.Version()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Layers(); !reflect.DeepEqual(got, []string{"base"}) {
+		t.Errorf("want: [base]; got: %v", got)
+	}
+}
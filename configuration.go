@@ -4,6 +4,7 @@ package props
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"math"
 	"regexp"
@@ -16,10 +17,40 @@ const (
 	// EncryptNone represents a value that has not yet been encrypted
 	EncryptNone = "[enc:0]"
 	// EncryptAESGCM represents a value that has been encryped with AES-GCM
+	// using the password as the key; the password must be 16, 24, or 32 bytes
 	EncryptAESGCM = "[enc:1]"
+	// EncryptAESGCMPBKDF2 represents a value that has been encrypted with
+	// AES-256-GCM using a key derived from the password with PBKDF2-SHA256
+	EncryptAESGCMPBKDF2 = "[enc:2]"
+	// EncryptXChaCha20 represents a value that has been encrypted with
+	// XChaCha20-Poly1305 using a key derived from the password with
+	// PBKDF2-SHA256
+	EncryptXChaCha20 = "[enc:3]"
+	// EncryptAESGCMBound represents a value that has been encrypted as with
+	// EncryptAESGCMPBKDF2 and bound to its property key (see EncryptBound)
+	EncryptAESGCMBound = "[enc:4]"
+	// EncryptXChaCha20Bound represents a value that has been encrypted as with
+	// EncryptXChaCha20 and bound to its property key (see EncryptBound)
+	EncryptXChaCha20Bound = "[enc:5]"
+	// EncryptX25519 represents a value that has been encrypted to an X25519
+	// public key with XChaCha20-Poly1305; the password is the public key to
+	// encrypt and the private key to decrypt (see GenerateX25519Key)
+	EncryptX25519 = "[enc:6]"
+	// EncryptX25519Bound represents a value that has been encrypted as with
+	// EncryptX25519 and bound to its property key (see EncryptBound)
+	EncryptX25519Bound = "[enc:7]"
+	// EncryptAESGCMKeyBound represents a value that has been encrypted as with
+	// EncryptAESGCM and bound to its property key (see EncryptBound); it is
+	// used for values encrypted with a file's data key (see DataKey)
+	EncryptAESGCMKeyBound = "[enc:8]"
 
-	// EncryptDefault represents the default encryption algorithm
+	// EncryptDefault represents the default encryption algorithm. It is kept
+	// as EncryptAESGCM so that values remain readable by older versions; new
+	// code should use EncryptRecommended.
 	EncryptDefault = EncryptAESGCM
+	// EncryptRecommended represents the recommended encryption algorithm for
+	// new values, which accepts any password
+	EncryptRecommended = EncryptAESGCMPBKDF2
 )
 
 // sizePattern provides the expression used for matching size values
@@ -42,6 +73,33 @@ type Configuration struct {
 	// additional "boolean-like" values are accepted such as 0 and 1. See
 	// ParseBool for details.
 	StrictBool bool
+	// BindContext provides the additional context, such as a file or profile
+	// name, that encrypted values were bound to with EncryptBound. The property
+	// key is always included by Decrypt.
+	BindContext []string
+}
+
+// ConfigOptions provides additional sources and settings for
+// NewConfigurationWith.
+type ConfigOptions struct {
+	// EnvPrefix limits the environment variables to those that start with the
+	// prefix (see Environment.Prefix).
+	EnvPrefix string
+	// EnvFiles indicates that environment variables may be provided by files
+	// named in '*_FILE' variables (see Environment.Files).
+	EnvFiles bool
+	// Dirs provides directories with one file per property, such as mounted
+	// secrets or config maps (see Directory). They are used in order after
+	// environment variables.
+	Dirs []fs.FS
+	// DotEnv provides the name of a dotenv file (such as ".env") to read
+	// environment variables from (see LoadDotEnv). Its variables are looked up
+	// the same way as environment variables but with a lower priority. The
+	// file is skipped if it does not exist.
+	DotEnv string
+	// Signer, if set, is used to verify the signature of each property file
+	// (see LoadVerified). Files that are unsigned or modified are refused.
+	Signer Signer
 }
 
 // NewConfiguration creates a Configuration using common conventions.
@@ -50,54 +108,155 @@ type Configuration struct {
 // following priority order:
 //  1. Command line arguments
 //  2. Environment variables
-//  3. <prefix>-<profile>.properties for the provided prefix and profiles
-//     values (in order)
-//  4. <prefix>.properties for the provided prefix value
+//  3. <prefix>-<profile>.<ext> for the provided prefix and profiles values (in
+//     order)
+//  4. <prefix>.<ext> for the provided prefix value
+//
+// The file extensions searched, in priority order, are: properties, yaml, yml,
+// json, toml, and ini. YAML, JSON, and TOML files are flattened into property
+// keys (see Flatten) and INI sections become key prefixes (see LoadINI). For
+// example, app-dev.yaml has a higher priority than app-dev.json which has a
+// higher priority than app.properties.
 //
 // The first matching property value found will be returned.
 //
 // An error will be returned if one of the property files could not be read or
 // parsed.
 func NewConfiguration(fileSys fs.StatFS, prefix string, profiles ...string) (*Configuration, error) {
+	return NewConfigurationWith(fileSys, ConfigOptions{}, prefix, profiles...)
+}
+
+// NewConfigurationWith creates a Configuration using common conventions with
+// additional sources provided by the options.
+//
+// The returned Configuration uses an Expander to return properties in the
+// following priority order:
+//  1. Command line arguments
+//  2. Environment variables (including those provided by files if EnvFiles is
+//     set)
+//  3. Variables from the DotEnv file
+//  4. Files in the directories provided by Dirs (in order)
+//  5. <prefix>-<profile>.<ext> for the provided prefix and profiles values (in
+//     order)
+//  6. <prefix>.<ext> for the provided prefix value
+//
+// The file extensions are searched as described by NewConfiguration.
+//
+// The first matching property value found will be returned.
+//
+// The sources are named layers of a Combined (see Configuration.Combined) so
+// that they can be changed later. The layers are named "args", "env",
+// "dotenv", "dirs[0]" (and so on for each directory), and the file name for
+// each property file, such as "app-dev.yaml".
+//
+// An error will be returned if one of the property files could not be read or
+// parsed, or if its signature could not be verified when opts.Signer is set.
+func NewConfigurationWith(fileSys fs.StatFS, opts ConfigOptions, prefix string, profiles ...string) (*Configuration, error) {
 	c := &Combined{}
-	c.Sources = make([]PropertyGetter, 0)
-	c.Sources = append(c.Sources, &Arguments{})
-	c.Sources = append(c.Sources, &Environment{Normalize: true})
+	c.AddLast("args", &Arguments{})
+	c.AddLast("env", &Environment{Normalize: true, Prefix: opts.EnvPrefix, Files: opts.EnvFiles})
+
+	if opts.DotEnv != "" {
+		env, err := loadDotEnv(fileSys, opts.DotEnv)
+		if err != nil {
+			return nil, err
+		}
+		if env != nil {
+			env.Normalize = true
+			env.Prefix = opts.EnvPrefix
+			env.Files = opts.EnvFiles
+			c.AddLast("dotenv", env)
+		}
+	}
 
+	for i, dir := range opts.Dirs {
+		c.AddLast("dirs["+strconv.Itoa(i)+"]", &Directory{FS: dir})
+	}
+
+	bases := make([]string, 0, len(profiles)+1)
 	for _, profile := range profiles {
-		filename := prefix + "-" + profile + ".properties"
-		stat, err := fileSys.Stat(filename)
-		if err == nil && !stat.IsDir() {
-			p := NewProperties()
-			f, err := fileSys.Open(filename)
+		bases = append(bases, prefix+"-"+profile)
+	}
+	bases = append(bases, prefix)
+
+	for _, base := range bases {
+		for _, doc := range documentReaders {
+			p, err := loadFile(fileSys, base+doc.ext, doc.read, opts.Signer)
 			if err != nil {
 				return nil, err
 			}
-			defer f.Close()
-			err = p.Load(f)
-			if err != nil {
-				return nil, err
+			if p != nil {
+				c.AddLast(base+doc.ext, p)
 			}
-			c.Sources = append(c.Sources, p)
 		}
 	}
+	return &Configuration{Props: NewExpander(c)}, nil
+}
 
-	filename := prefix + ".properties"
-	stat, err := fileSys.Stat(filename)
-	if err == nil && !stat.IsDir() {
-		p := NewProperties()
-		f, err := fileSys.Open(filename)
-		if err != nil {
-			return nil, err
+// Combined returns the Combined that provides the configuration's properties,
+// either directly or as the source of an Expander, such as the one created by
+// NewConfiguration. This allows layers and overrides to be changed after the
+// configuration is created. If there is no Combined, nil is returned.
+func (c *Configuration) Combined() *Combined {
+	switch p := c.Props.(type) {
+	case *Combined:
+		return p
+	case *Expander:
+		if comb, ok := p.Source.(*Combined); ok {
+			return comb
 		}
-		defer f.Close()
-		err = p.Load(f)
-		if err != nil {
-			return nil, err
-		}
-		c.Sources = append(c.Sources, p)
 	}
-	return &Configuration{Props: NewExpander(c)}, nil
+	return nil
+}
+
+// loadDotEnv reads a dotenv file into an Environment if it exists. If the file
+// does not exist, nil is returned with no error.
+func loadDotEnv(fileSys fs.StatFS, filename string) (*Environment, error) {
+	stat, err := fileSys.Stat(filename)
+	if err != nil || stat.IsDir() {
+		return nil, nil
+	}
+
+	f, err := fileSys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := ReadDotEnv(f)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	for _, k := range p.Names() {
+		vars[k], _ = p.Get(k)
+	}
+	return &Environment{Vars: vars}, nil
+}
+
+// loadFile reads a property file with the provided function if it exists. If
+// the file does not exist, nil is returned with no error. If a signer is
+// provided, the file's signature is verified.
+func loadFile(fileSys fs.StatFS, filename string, read func(io.Reader) (*Properties, error), s Signer) (*Properties, error) {
+	stat, err := fileSys.Stat(filename)
+	if err != nil || stat.IsDir() {
+		return nil, nil
+	}
+	if s != nil {
+		return LoadVerified(fileSys, filename, s)
+	}
+
+	f, err := fileSys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s [%w]", filename, err)
+	}
+	return p, nil
 }
 
 // Get retrieves the value of a property. If the property does not exist, an
@@ -430,13 +589,34 @@ func (c *Configuration) ParseDate(key string, defVal time.Time) (time.Time, erro
 }
 
 // Decrypt returns the plaintext value of a property encrypted with the Encrypt
-// function. If the property does not exist, then the default value will be
-// returned with a nil error. If the property value could not be decrypted,
-// then an error and the default value will be returned.
+// or EncryptBound function. Values using a bound algorithm are authenticated
+// with the property key and BindContext. If the property does not exist, then
+// the default value will be returned with a nil error. If the property value
+// could not be decrypted, then an error and the default value will be
+// returned.
 func (c *Configuration) Decrypt(password string, key string, defVal string) (string, error) {
 	val, ok := c.Props.Get(key)
 	if ok {
-		dec, err := Decrypt(password, val)
+		dec, err := DecryptBound(password, val, key, c.BindContext...)
+		if err != nil {
+			return defVal, fmt.Errorf("invalid encrypted value for %s [%w]", val, err)
+		}
+		return dec, nil
+	} else {
+		return defVal, nil
+	}
+}
+
+// DecryptWith returns the plaintext value of a property encrypted with the
+// Encrypt or EncryptWith functions using the key named in the value from the
+// provider. Values using a bound algorithm are authenticated with the property
+// key and BindContext. If the property does not exist, then the default value
+// will be returned with a nil error. If the property value could not be
+// decrypted, then an error and the default value will be returned.
+func (c *Configuration) DecryptWith(p KeyProvider, key string, defVal string) (string, error) {
+	val, ok := c.Props.Get(key)
+	if ok {
+		dec, err := DecryptBoundWith(p, val, key, c.BindContext...)
 		if err != nil {
 			return defVal, fmt.Errorf("invalid encrypted value for %s [%w]", val, err)
 		}
@@ -445,3 +625,35 @@ func (c *Configuration) Decrypt(password string, key string, defVal string) (str
 		return defVal, nil
 	}
 }
+
+// DecryptSecret returns the value of a property encrypted with the Encrypt or
+// EncryptBound function as a Secret that is redacted when formatted or logged.
+// Values are decrypted as described by Decrypt. If the property does not
+// exist, then nil will be returned with a nil error; a nil Secret reveals an
+// empty value.
+func (c *Configuration) DecryptSecret(password string, key string) (*Secret, error) {
+	val, ok := c.Props.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	dec, err := decrypt(password, val, boundData(key, c.BindContext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted value for %s [%w]", key, err)
+	}
+	return NewSecret(dec), nil
+}
+
+// DecryptSecretWith returns the value of a property as a Secret using the key
+// named in the value from the provider. See DecryptSecret and DecryptWith for
+// details.
+func (c *Configuration) DecryptSecretWith(p KeyProvider, key string) (*Secret, error) {
+	val, ok := c.Props.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	dec, err := decryptWith(p, val, boundData(key, c.BindContext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted value for %s [%w]", key, err)
+	}
+	return NewSecret(dec), nil
+}
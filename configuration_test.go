@@ -6,6 +6,8 @@ import (
 	"errors"
 	"io/fs"
 	"math"
+	"os"
+	"reflect"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -572,3 +574,211 @@ func TestConfigDecrypt(t *testing.T) {
 		t.Errorf("want: 'default', err != nil; got: %s, %v", val, err)
 	}
 }
+
+func TestNewConfigurationWith(t *testing.T) {
+	os.Setenv("PROPSCONF_KEY1", "env")
+	os.Setenv("PROPSCONF_SECRET_FILE", "/nonexistent/secret")
+
+	secrets := fstest.MapFS{
+		"key1":   &fstest.MapFile{Data: []byte("dir")},
+		"key2":   &fstest.MapFile{Data: []byte("dir")},
+		"secret": &fstest.MapFile{Data: []byte("dir-secret")},
+	}
+	c, err := NewConfigurationWith(memFs, ConfigOptions{
+		EnvPrefix: "PROPSCONF_",
+		EnvFiles:  true,
+		Dirs:      []fs.FS{secrets},
+	}, "testapp", "test")
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	tests := map[string]string{
+		"key1":   "env",
+		"key2":   "dir",
+		"key3":   "",
+		"keyexp": "dir",
+		"secret": "dir-secret",
+	}
+	for k, want := range tests {
+		if got, _ := c.Get(k); got != want {
+			t.Errorf("%s want: '%s'; got: '%s'", k, want, got)
+		}
+	}
+
+	_, err = NewConfigurationWith(&badFs{}, ConfigOptions{}, "bad-read")
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestNewConfigurationDotEnv(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.properties": &fstest.MapFile{Data: []byte("db.host=file\ndb.port=1\n")},
+		".env":           &fstest.MapFile{Data: []byte("DBENV_DB_HOST=dotenv\nDBENV_DB_USER=\"user\"\n")},
+		"bad.env":        &fstest.MapFile{Data: []byte("BAD")},
+	}
+	os.Setenv("DBENV_DB_USER", "env")
+
+	c, err := NewConfigurationWith(fsys, ConfigOptions{DotEnv: ".env", EnvPrefix: "DBENV_"}, "app")
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	tests := map[string]string{"db.host": "dotenv", "db.port": "1", "db.user": "env"}
+	for k, want := range tests {
+		if got, _ := c.Get(k); got != want {
+			t.Errorf("%s want: '%s'; got: '%s'", k, want, got)
+		}
+	}
+
+	_, err = NewConfigurationWith(fsys, ConfigOptions{DotEnv: "none.env"}, "app")
+	if err != nil {
+		t.Errorf("got error: %v", err)
+	}
+
+	_, err = NewConfigurationWith(fsys, ConfigOptions{DotEnv: "bad.env"}, "app")
+	if err == nil {
+		t.Error("want: error; got: nil")
+	}
+
+	_, err = NewConfigurationWith(&badFs{}, ConfigOptions{DotEnv: "bad-read.properties"}, "app")
+	if err == nil {
+		t.Error("want: error; got: nil")
+	}
+
+	_, err = NewConfigurationWith(&badFs{}, ConfigOptions{DotEnv: ".env"}, "app")
+	if err == nil {
+		t.Error("want: error; got: nil")
+	}
+}
+
+func TestNewConfigurationDocuments(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docapp.properties":  &fstest.MapFile{Data: []byte("a=base-props\nb=base-props\nc=base-props\n")},
+		"docapp.toml":        &fstest.MapFile{Data: []byte("a = \"base-toml\"\nd = \"base-toml\"\n")},
+		"docapp-dev.json":    &fstest.MapFile{Data: []byte(`{"a": "dev-json", "b": "dev-json", "db": {"port": 5432}}`)},
+		"docapp-dev.yml":     &fstest.MapFile{Data: []byte("a: dev-yml\nb: dev-yml\n")},
+		"docapp-dev.yaml":    &fstest.MapFile{Data: []byte("a: dev-yaml\n")},
+		"badapp.json":        &fstest.MapFile{Data: []byte(`{"a": `)},
+		"docapp-other.yaml":  &fstest.MapFile{Data: []byte("e: other\n")},
+		"docapp-unused.yaml": &fstest.MapFile{Data: []byte("a: unused\n")},
+	}
+	c, err := NewConfigurationWith(fsys, ConfigOptions{}, "docapp", "dev", "other")
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	tests := map[string]string{
+		"a":       "dev-yaml",
+		"b":       "dev-yml",
+		"c":       "base-props",
+		"d":       "base-toml",
+		"e":       "other",
+		"db.port": "5432",
+	}
+	for k, want := range tests {
+		if got, _ := c.Get(k); got != want {
+			t.Errorf("%s want: '%s'; got: '%s'", k, want, got)
+		}
+	}
+
+	_, err = NewConfigurationWith(fsys, ConfigOptions{}, "badapp")
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestConfigurationCombined(t *testing.T) {
+	secrets := fstest.MapFS{"secret": &fstest.MapFile{Data: []byte("dir")}}
+	c, err := NewConfigurationWith(memFs, ConfigOptions{Dirs: []fs.FS{secrets}}, "testapp", "test")
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	comb := c.Combined()
+	if comb == nil {
+		t.Fatalf("want: combined; got: nil")
+	}
+	want := []string{"args", "env", "dirs[0]", "testapp-test.properties", "testapp.properties"}
+	if got := comb.Layers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	extra := NewProperties()
+	extra.Set("key1", "extra")
+	if err := comb.AddBefore("env", "extra", extra); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := c.Get("key1"); v != "extra" {
+		t.Errorf("want: extra; got: %s", v)
+	}
+	comb.SetOverride("key1", "pinned")
+	if v, _ := c.Get("key1"); v != "pinned" {
+		t.Errorf("want: pinned; got: %s", v)
+	}
+
+	if (&Configuration{Props: comb}).Combined() != comb {
+		t.Errorf("want: combined; got: other")
+	}
+	if (&Configuration{Props: NewProperties()}).Combined() != nil {
+		t.Errorf("want: nil; got: combined")
+	}
+}
+
+func TestConfigDecryptBound(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	dbPass, _ := EncryptBound(EncryptAESGCMBound, "pass", "db-secret", "db.password")
+	prodPass, _ := EncryptBound(EncryptXChaCha20Bound, "pass", "prod-secret", "api.key", "prod")
+
+	p := NewProperties()
+	p.Set("db.password", dbPass)
+	p.Set("admin.password", dbPass)
+	p.Set("api.key", prodPass)
+	c := &Configuration{Props: p}
+
+	val, err := c.Decrypt("pass", "db.password", "default")
+	if val != "db-secret" || err != nil {
+		t.Errorf("want: 'db-secret', err == nil; got: %s, %v", val, err)
+	}
+	val, err = c.Decrypt("pass", "admin.password", "default")
+	if val != "default" || err == nil {
+		t.Errorf("want: 'default', err != nil; got: %s, %v", val, err)
+	}
+	val, err = c.Decrypt("pass", "api.key", "default")
+	if val != "default" || err == nil {
+		t.Errorf("want: 'default', err != nil; got: %s, %v", val, err)
+	}
+
+	c.BindContext = []string{"prod"}
+	val, err = c.Decrypt("pass", "api.key", "default")
+	if val != "prod-secret" || err != nil {
+		t.Errorf("want: 'prod-secret', err == nil; got: %s, %v", val, err)
+	}
+}
+
+func TestConfigDecryptWith(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	kr := &Keyring{Primary: "k1", Keys: map[string]string{"k1": "pass"}}
+	dbPass, _ := kr.EncryptBound(EncryptAESGCMBound, "db-secret", "db.password", "prod")
+
+	p := NewProperties()
+	p.Set("db.password", dbPass)
+	c := &Configuration{Props: p, BindContext: []string{"prod"}}
+
+	val, err := c.DecryptWith(kr, "db.password", "default")
+	if val != "db-secret" || err != nil {
+		t.Errorf("want: 'db-secret', err == nil; got: %s, %v", val, err)
+	}
+	val, err = c.DecryptWith(kr, "missing", "default")
+	if val != "default" || err != nil {
+		t.Errorf("want: 'default', err == nil; got: %s, %v", val, err)
+	}
+	val, err = c.DecryptWith(&Keyring{Primary: "k2"}, "db.password", "default")
+	if val != "default" || err == nil {
+		t.Errorf("want: 'default', err != nil; got: %s, %v", val, err)
+	}
+}
@@ -0,0 +1,79 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// Directory reads properties from the files in a directory. Each file name is
+// a property key and the file contents, with leading and trailing whitespace
+// removed, are the value.
+//
+// This matches the layout used by Docker secrets (/run/secrets) and mounted
+// Kubernetes ConfigMap and Secret volumes. Subdirectories and files with names
+// starting with '.' (such as the Kubernetes '..data' link) are ignored.
+//
+// For example:
+//
+//	d := &Directory{FS: os.DirFS("/run/secrets")}
+type Directory struct {
+	// FS provides the directory to read properties from.
+	FS fs.FS
+}
+
+// Ensure that Directory implements PropertyGetter
+var _ PropertyGetter = &Directory{}
+
+// Get retrieves the value of a property from the file with the same name. If
+// the file does not exist or could not be read, an empty string will be
+// returned. The bool return value indicates whether the property was found.
+func (d *Directory) Get(key string) (string, bool) {
+	if !isDirectoryKey(key) {
+		return "", false
+	}
+	data, err := fs.ReadFile(d.FS, key)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// GetDefault retrieves the value of a property from the file with the same
+// name. If the file does not exist or could not be read, then the default
+// value will be returned.
+func (d *Directory) GetDefault(key, defVal string) string {
+	v, ok := d.Get(key)
+	if !ok {
+		return defVal
+	}
+	return v
+}
+
+// Names returns the names of all files in the directory.
+func (d *Directory) Names() []string {
+	result := make([]string, 0, 8)
+	entries, err := fs.ReadDir(d.FS, ".")
+	if err != nil {
+		return result
+	}
+	for _, entry := range entries {
+		if !isDirectoryKey(entry.Name()) {
+			continue
+		}
+		// entries may be links so check the target
+		stat, err := fs.Stat(d.FS, entry.Name())
+		if err != nil || stat.IsDir() {
+			continue
+		}
+		result = append(result, entry.Name())
+	}
+	return result
+}
+
+// isDirectoryKey determines whether a key refers to a file directly within
+// the directory.
+func isDirectoryKey(key string) bool {
+	return fs.ValidPath(key) && key != "." && !strings.HasPrefix(key, ".") && !strings.Contains(key, "/")
+}
@@ -0,0 +1,72 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+var secretFs = fstest.MapFS{
+	"db.password":    &fstest.MapFile{Data: []byte("s3cret\n")},
+	"api.key":        &fstest.MapFile{Data: []byte("  abc  ")},
+	"empty":          &fstest.MapFile{Data: []byte("")},
+	".hidden":        &fstest.MapFile{Data: []byte("x")},
+	"..data/api.key": &fstest.MapFile{Data: []byte("abc")},
+	"sub/key":        &fstest.MapFile{Data: []byte("y")},
+}
+
+func TestDirectory(t *testing.T) {
+	d := &Directory{FS: secretFs}
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"db.password", "s3cret", true},
+		{"api.key", "abc", true},
+		{"empty", "", true},
+		{".hidden", "", false},
+		{"..data/api.key", "", false},
+		{"sub", "", false},
+		{"sub/key", "", false},
+		{"../db.password", "", false},
+		{"none", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		val, ok := d.Get(test.key)
+		if val != test.want || ok != test.found {
+			t.Errorf("%s want: %t, '%s'; got: %t, '%s'", test.key, test.found, test.want, ok, val)
+		}
+	}
+
+	if val := d.GetDefault("db.password", "def"); val != "s3cret" {
+		t.Errorf("want: 's3cret'; got: '%s'", val)
+	}
+	if val := d.GetDefault("none", "def"); val != "def" {
+		t.Errorf("want: 'def'; got: '%s'", val)
+	}
+
+	got := d.Names()
+	sort.Strings(got)
+	want := []string{"api.key", "db.password", "empty"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+func TestDirectoryMissing(t *testing.T) {
+	d := &Directory{FS: fstest.MapFS{}}
+	if got := d.Names(); len(got) != 0 {
+		t.Errorf("want: []; got: %v", got)
+	}
+
+	d = &Directory{FS: &badFs{}}
+	if got := d.Names(); len(got) != 0 {
+		t.Errorf("want: []; got: %v", got)
+	}
+}
@@ -0,0 +1,164 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// documentReaders provides the functions used to read property files by
+// extension in the order they are searched by NewConfiguration.
+var documentReaders = []struct {
+	ext  string
+	read func(io.Reader) (*Properties, error)
+}{
+	{".properties", Read},
+	{".yaml", ReadYAML},
+	{".yml", ReadYAML},
+	{".json", ReadJSON},
+	{".toml", ReadTOML},
+	{".ini", ReadINI},
+}
+
+// documentReader returns the function used to read a property file based on
+// its extension. Files with an unknown extension are read as properties.
+func documentReader(name string) func(io.Reader) (*Properties, error) {
+	ext := path.Ext(name)
+	for _, doc := range documentReaders {
+		if doc.ext == ext {
+			return doc.read
+		}
+	}
+	if ext == ".env" {
+		return ReadDotEnv
+	}
+	return Read
+}
+
+// ReadJSON creates a new property set from a JSON document. The document is
+// flattened into property keys as described by Flatten.
+func ReadJSON(r io.Reader) (*Properties, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc interface{}
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json document [%w]", err)
+	}
+	return Flatten(doc), nil
+}
+
+// ReadYAML creates a new property set from a YAML document. The document is
+// flattened into property keys as described by Flatten.
+func ReadYAML(r io.Reader) (*Properties, error) {
+	var doc interface{}
+	err := yaml.NewDecoder(r).Decode(&doc)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid yaml document [%w]", err)
+	}
+	return Flatten(doc), nil
+}
+
+// ReadTOML creates a new property set from a TOML document. The document is
+// flattened into property keys as described by Flatten.
+func ReadTOML(r io.Reader) (*Properties, error) {
+	var doc map[string]interface{}
+	_, err := toml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid toml document [%w]", err)
+	}
+	return Flatten(doc), nil
+}
+
+// Flatten creates a new property set from a nested document such as one
+// decoded from JSON or YAML. Nested maps are joined to their parent keys with
+// '.' and list items use the index in brackets.
+//
+// For example, the YAML document:
+//
+//	db:
+//	  host: localhost
+//	  port: 5432
+//	servers:
+//	  - host: a.example.com
+//	  - host: b.example.com
+//
+// would result in the following properties:
+//
+//	"db.host":         "localhost"
+//	"db.port":         "5432"
+//	"servers[0].host": "a.example.com"
+//	"servers[1].host": "b.example.com"
+//
+// Values are converted to strings; times use the RFC 3339 format and null
+// values become empty strings. Empty maps and lists are omitted.
+func Flatten(doc interface{}) *Properties {
+	p := NewProperties()
+	flatten(p, "", doc)
+	return p
+}
+
+// flatten adds the value to the property set using the key and recursively
+// adds any nested values.
+func flatten(p *Properties, key string, val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flatten(p, joinKey(key, k), v[k])
+		}
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			flatten(p, joinKey(key, fmt.Sprint(k)), item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(p, key+"["+strconv.Itoa(i)+"]", item)
+		}
+	case []map[string]interface{}:
+		for i, item := range v {
+			flatten(p, key+"["+strconv.Itoa(i)+"]", item)
+		}
+	default:
+		if key != "" {
+			p.Set(key, formatValue(val))
+		}
+	}
+}
+
+// joinKey adds a nested key name to its parent key.
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// formatValue converts a single document value into a property value.
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,185 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var documentWant = map[string]string{
+	"name":            "app",
+	"debug":           "true",
+	"db.host":         "localhost",
+	"db.port":         "5432",
+	"db.timeout":      "1.5",
+	"db.empty":        "",
+	"servers[0].host": "a.example.com",
+	"servers[0].port": "80",
+	"servers[1].host": "b.example.com",
+	"servers[1].port": "8080",
+	"tags[0]":         "x",
+	"tags[1]":         "y",
+}
+
+func TestReadJSON(t *testing.T) {
+	doc := `{
+		"name": "app",
+		"debug": true,
+		"db": {"host": "localhost", "port": 5432, "timeout": 1.5, "empty": null},
+		"servers": [
+			{"host": "a.example.com", "port": 80},
+			{"host": "b.example.com", "port": 8080}
+		],
+		"tags": ["x", "y"],
+		"none": {},
+		"list": []
+	}`
+	p, err := ReadJSON(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if !reflect.DeepEqual(documentWant, p.values) {
+		t.Errorf("want: %v; got: %v", documentWant, p.values)
+	}
+
+	p, err = ReadJSON(strings.NewReader(`{"big": 12345678901234567890, "exp": 1e3}`))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := p.Get("big"); v != "12345678901234567890" {
+		t.Errorf("want: 12345678901234567890; got: %s", v)
+	}
+	if v, _ := p.Get("exp"); v != "1e3" {
+		t.Errorf("want: 1e3; got: %s", v)
+	}
+
+	_, err = ReadJSON(strings.NewReader(`{"a": `))
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+	_, err = ReadJSON(&ErrorReader{})
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestReadYAML(t *testing.T) {
+	doc := `
+name: app
+debug: true
+db:
+  host: localhost
+  port: 5432
+  timeout: 1.5
+  empty: ~
+servers:
+  - host: a.example.com
+    port: 80
+  - host: b.example.com
+    port: 8080
+tags: [x, y]
+none: {}
+`
+	p, err := ReadYAML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if !reflect.DeepEqual(documentWant, p.values) {
+		t.Errorf("want: %v; got: %v", documentWant, p.values)
+	}
+
+	p, err = ReadYAML(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if len(p.values) != 0 {
+		t.Errorf("want: empty; got: %v", p.values)
+	}
+
+	p, err = ReadYAML(strings.NewReader("1: one\ntrue: yes\n"))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := p.Get("1"); v != "one" {
+		t.Errorf("want: one; got: %s", v)
+	}
+	if v, _ := p.Get("true"); v != "yes" {
+		t.Errorf("want: yes; got: %s", v)
+	}
+
+	_, err = ReadYAML(strings.NewReader("a: [b"))
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestReadTOML(t *testing.T) {
+	doc := `
+name = "app"
+debug = true
+tags = ["x", "y"]
+
+[db]
+host = "localhost"
+port = 5432
+timeout = 1.5
+
+[[servers]]
+host = "a.example.com"
+port = 80
+
+[[servers]]
+host = "b.example.com"
+port = 8080
+`
+	p, err := ReadTOML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	want := make(map[string]string, len(documentWant))
+	for k, v := range documentWant {
+		want[k] = v
+	}
+	delete(want, "db.empty") // TOML has no null
+	if !reflect.DeepEqual(want, p.values) {
+		t.Errorf("want: %v; got: %v", want, p.values)
+	}
+
+	p, err = ReadTOML(strings.NewReader("when = 2026-01-02T03:04:05Z\n"))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := p.Get("when"); v != "2026-01-02T03:04:05Z" {
+		t.Errorf("want: 2026-01-02T03:04:05Z; got: %s", v)
+	}
+
+	_, err = ReadTOML(strings.NewReader("a = "))
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		doc  interface{}
+		want map[string]string
+	}{
+		{nil, map[string]string{}},
+		{"scalar", map[string]string{}},
+		{map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, nil}}},
+			map[string]string{"a.b[0]": "1", "a.b[1]": ""}},
+		{[]interface{}{"a", []interface{}{"b"}}, map[string]string{"[0]": "a", "[1][0]": "b"}},
+		{map[string]interface{}{"f": 0.000001, "t": time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)},
+			map[string]string{"f": "0.000001", "t": "2026-01-02T03:04:05.000000006Z"}},
+	}
+
+	for i, test := range tests {
+		p := Flatten(test.doc)
+		if !reflect.DeepEqual(test.want, p.values) {
+			t.Errorf("%d want: %v; got: %v", i, test.want, p.values)
+		}
+	}
+}
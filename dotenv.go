@@ -0,0 +1,320 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadDotEnv creates a new property set and fills it with the contents of a
+// dotenv file. See LoadDotEnv for the supported file format.
+func ReadDotEnv(r io.Reader) (*Properties, error) {
+	p := NewProperties()
+	err := p.LoadDotEnv(r)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+/*
+LoadDotEnv reads the contents of a dotenv (.env) file. Existing properties
+will be retained. The contents of the file will override any existing
+properties with matching keys.
+
+# File Format
+
+Each line of the file represents a "KEY=value" pair and may start with
+"export ". Whitespace around the key and '=' is ignored. Blank lines and lines
+starting with '#' are ignored.
+
+Values may be written in one of three ways:
+  - Unquoted values end at the end of the line or at a '#' that follows
+    whitespace; leading and trailing whitespace is removed.
+  - Single quoted values are used exactly as written and may span multiple
+    lines.
+  - Double quoted values may span multiple lines and support the escapes
+    '\n', '\r', '\t', '\"', '\\', and '\$'.
+
+A comment may follow a quoted value on the same line.
+
+# Interpolation
+
+Unquoted and double quoted values may refer to other variables with ${VAR} or
+$VAR. Variables defined earlier in the file are used first followed by the OS
+environment. A default can be given with ${VAR:-default} which is used if the
+variable is not set or is empty. References to missing variables are replaced
+with an empty string.
+
+# Sample File
+
+	# .env
+	export DB_HOST=localhost
+	DB_URL="postgres://${DB_HOST}:5432/app"   # interpolated
+	GREETING='Hello $USER'                    # not interpolated
+	CERT="-----BEGIN CERTIFICATE-----
+	MIIB...
+	-----END CERTIFICATE-----"
+*/
+func (p *Properties) LoadDotEnv(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	defer func() { p.version++ }()
+
+	d := &dotEnv{src: string(data), line: 1, p: p, load: p.loader()}
+	for !d.eof() {
+		err = d.entry()
+		if err != nil {
+			return err
+		}
+	}
+	return d.load.finish()
+}
+
+// dotEnv holds the state for parsing a dotenv file.
+type dotEnv struct {
+	src  string
+	pos  int
+	line int
+	p    *Properties
+	load *loader
+}
+
+// eof determines whether the whole file has been read.
+func (d *dotEnv) eof() bool {
+	return d.pos >= len(d.src)
+}
+
+// peek returns the next byte without consuming it or 0 at the end of the file.
+func (d *dotEnv) peek() byte {
+	if d.eof() {
+		return 0
+	}
+	return d.src[d.pos]
+}
+
+// next consumes and returns the next byte.
+func (d *dotEnv) next() byte {
+	ch := d.src[d.pos]
+	d.pos++
+	if ch == '\n' {
+		d.line++
+	}
+	return ch
+}
+
+// skipSpace consumes spaces and tabs.
+func (d *dotEnv) skipSpace() {
+	for d.peek() == ' ' || d.peek() == '\t' {
+		d.next()
+	}
+}
+
+// skipLine consumes everything up to and including the next newline.
+func (d *dotEnv) skipLine() {
+	for !d.eof() {
+		if d.next() == '\n' {
+			return
+		}
+	}
+}
+
+// errorf creates an error that includes the current line number.
+func (d *dotEnv) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid dotenv line %d: %s", d.line, fmt.Sprintf(format, args...))
+}
+
+// entry reads a single line or key-value pair.
+func (d *dotEnv) entry() error {
+	d.skipSpace()
+	if d.peek() == '#' || d.peek() == '\n' || d.peek() == '\r' || d.eof() {
+		d.skipLine()
+		return nil
+	}
+
+	if strings.HasPrefix(d.src[d.pos:], "export ") || strings.HasPrefix(d.src[d.pos:], "export\t") {
+		d.pos += len("export")
+		d.skipSpace()
+	}
+
+	start := d.pos
+	for !d.eof() && isDotEnvKey(d.peek()) {
+		d.next()
+	}
+	key := d.src[start:d.pos]
+	if key == "" {
+		return d.errorf("missing key")
+	}
+
+	d.skipSpace()
+	if d.peek() != '=' {
+		return d.errorf("missing '=' after %s", key)
+	}
+	d.next()
+	d.skipSpace()
+
+	var val string
+	var err error
+	switch d.peek() {
+	case '\'':
+		val, err = d.singleQuoted()
+	case '"':
+		val, err = d.doubleQuoted()
+	default:
+		val = d.unquoted()
+	}
+	if err != nil {
+		return err
+	}
+
+	d.skipSpace()
+	switch d.peek() {
+	case '#', '\r', '\n', 0:
+		d.skipLine()
+	default:
+		return d.errorf("unexpected %q after value for %s", d.peek(), key)
+	}
+
+	d.load.add(key, val)
+	return nil
+}
+
+// unquoted reads a value up to the end of the line or a comment.
+func (d *dotEnv) unquoted() string {
+	start := d.pos
+	for !d.eof() && d.peek() != '\n' {
+		if d.peek() == '#' && d.pos > start && (d.src[d.pos-1] == ' ' || d.src[d.pos-1] == '\t') {
+			break
+		}
+		d.next()
+	}
+	return d.interpolate(strings.TrimSpace(d.src[start:d.pos]))
+}
+
+// singleQuoted reads a value with no escapes or interpolation.
+func (d *dotEnv) singleQuoted() (string, error) {
+	line := d.line
+	d.next()
+	start := d.pos
+	for !d.eof() {
+		if d.next() == '\'' {
+			return d.src[start : d.pos-1], nil
+		}
+	}
+	return "", fmt.Errorf("invalid dotenv line %d: unterminated quoted value", line)
+}
+
+// doubleQuoted reads a value with escapes and interpolation.
+func (d *dotEnv) doubleQuoted() (string, error) {
+	line := d.line
+	d.next()
+	var buf strings.Builder
+	for !d.eof() {
+		ch := d.next()
+		switch ch {
+		case '"':
+			return buf.String(), nil
+		case '\\':
+			if d.eof() {
+				break
+			}
+			esc := d.next()
+			switch esc {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case '"', '\\', '$':
+				buf.WriteByte(esc)
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(esc)
+			}
+		case '$':
+			buf.WriteString(d.reference())
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	return "", fmt.Errorf("invalid dotenv line %d: unterminated quoted value", line)
+}
+
+// reference reads a variable reference following a '$' and returns its
+// value.
+func (d *dotEnv) reference() string {
+	if d.peek() == '{' {
+		start := d.pos
+		end := strings.IndexByte(d.src[start:], '}')
+		if end < 0 || strings.ContainsAny(d.src[start:start+end], "\"\n") {
+			return "$"
+		}
+		for d.pos <= start+end {
+			d.next()
+		}
+		return d.lookup(d.src[start+1 : start+end])
+	}
+
+	start := d.pos
+	if ch := d.peek(); ch >= '0' && ch <= '9' {
+		return "$"
+	}
+	for !d.eof() && isDotEnvName(d.peek()) {
+		d.next()
+	}
+	if start == d.pos {
+		return "$"
+	}
+	return d.lookup(d.src[start:d.pos])
+}
+
+// interpolate replaces variable references in an unquoted value.
+func (d *dotEnv) interpolate(v string) string {
+	if !strings.Contains(v, "$") {
+		return v
+	}
+	sub := &dotEnv{src: v, p: d.p}
+	var buf strings.Builder
+	for !sub.eof() {
+		ch := sub.next()
+		if ch == '$' {
+			buf.WriteString(sub.reference())
+		} else if ch == '\\' && sub.peek() == '$' {
+			buf.WriteByte(sub.next())
+		} else {
+			buf.WriteByte(ch)
+		}
+	}
+	return buf.String()
+}
+
+// lookup finds the value of a variable reference of the form "VAR" or
+// "VAR:-default".
+func (d *dotEnv) lookup(ref string) string {
+	name, def, hasDef := strings.Cut(ref, ":-")
+	v, ok := d.p.Get(name)
+	if !ok {
+		v, ok = os.LookupEnv(name)
+	}
+	if hasDef && (!ok || v == "") {
+		return def
+	}
+	return v
+}
+
+// isDotEnvKey returns true for characters allowed in a dotenv key.
+func isDotEnvKey(ch byte) bool {
+	return isDotEnvName(ch) || ch == '.' || ch == '-'
+}
+
+// isDotEnvName returns true for characters allowed in a $VAR reference.
+func isDotEnvName(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
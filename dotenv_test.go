@@ -0,0 +1,136 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dotEnvFile = `
+# comment
+  # indented comment
+export DB_HOST=localhost
+DB_PORT = 5432
+DB_URL="postgres://${DB_HOST}:$DB_PORT/app"  # comment after quotes
+RAW='no $DB_HOST \n escapes' # comment
+PLAIN=value with spaces   # trailing comment
+HASH=abc#def
+EMPTY=
+EMPTY_QUOTED=""
+ESCAPES="a\tb\nc \"q\" \\ \$DB_HOST \x"
+UNQUOTED_ESC=\$DB_HOST
+MULTI="line 1
+line 2"
+MULTI_RAW='line 1
+line 2'
+DEFAULT=${MISSING:-fallback}
+DEFAULT_SET=${DB_HOST:-fallback}
+MISSING_REF=a${MISSING}b
+FROM_ENV=${PROPS_DOTENV_TEST}
+DOLLAR="cost $5 and $"
+UNCLOSED="${DB_HOST"
+CRLF=crlf` + "\r\n" + `export	TABBED=tab
+LAST=end`
+
+func TestLoadDotEnv(t *testing.T) {
+	os.Setenv("PROPS_DOTENV_TEST", "from env")
+	p, err := ReadDotEnv(strings.NewReader(dotEnvFile))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	want := map[string]string{
+		"DB_HOST":      "localhost",
+		"DB_PORT":      "5432",
+		"DB_URL":       "postgres://localhost:5432/app",
+		"RAW":          `no $DB_HOST \n escapes`,
+		"PLAIN":        "value with spaces",
+		"HASH":         "abc#def",
+		"EMPTY":        "",
+		"EMPTY_QUOTED": "",
+		"ESCAPES":      "a\tb\nc \"q\" \\ $DB_HOST \\x",
+		"UNQUOTED_ESC": "$DB_HOST",
+		"MULTI":        "line 1\nline 2",
+		"MULTI_RAW":    "line 1\nline 2",
+		"DEFAULT":      "fallback",
+		"DEFAULT_SET":  "localhost",
+		"MISSING_REF":  "ab",
+		"FROM_ENV":     "from env",
+		"DOLLAR":       "cost $5 and $",
+		"UNCLOSED":     "${DB_HOST",
+		"CRLF":         "crlf",
+		"TABBED":       "tab",
+		"LAST":         "end",
+	}
+	if !reflect.DeepEqual(want, p.values) {
+		for k, v := range want {
+			if p.values[k] != v {
+				t.Errorf("%s want: %q; got: %q", k, v, p.values[k])
+			}
+		}
+		t.Errorf("want: %d values; got: %d", len(want), len(p.values))
+	}
+}
+
+var badDotEnv = []string{
+	"=value",
+	"KEY value",
+	"KEY='unterminated",
+	"KEY=\"unterminated",
+	"KEY=\"abc\\",
+	"KEY=\"abc\" extra",
+	"KEY='abc' extra",
+	"A=1\nB=2\n$KEY=3",
+}
+
+func TestLoadDotEnvErrors(t *testing.T) {
+	for _, test := range badDotEnv {
+		_, err := ReadDotEnv(strings.NewReader(test))
+		if err == nil {
+			t.Errorf("%q want: error; got: nil", test)
+		}
+	}
+
+	_, err := ReadDotEnv(strings.NewReader("A=1\nB=2\n!"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("want: line 3 error; got: %v", err)
+	}
+
+	_, err = ReadDotEnv(&ErrorReader{})
+	if err == nil {
+		t.Error("want: error; got: nil")
+	}
+}
+
+func TestLoadDotEnvExisting(t *testing.T) {
+	p := NewProperties()
+	p.Set("A", "1")
+	p.Set("B", "2")
+	if err := p.LoadDotEnv(bytes.NewBufferString("B=3\nC=${A}")); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	want := map[string]string{"A": "1", "B": "3", "C": "1"}
+	if !reflect.DeepEqual(want, p.values) {
+		t.Errorf("want: %v; got: %v", want, p.values)
+	}
+}
+
+func TestDotEnvDuplicates(t *testing.T) {
+	p := NewProperties()
+	p.Duplicates = DuplicateError
+	err := p.LoadDotEnv(strings.NewReader("A=1\nB=$A\nA=2\n"))
+	if dupErr, ok := err.(*DuplicateKeyError); !ok || !reflect.DeepEqual(dupErr.Keys, []string{"A"}) {
+		t.Errorf("want: duplicate A; got: %v", err)
+	}
+	if v, _ := p.Get("A"); v != "2" {
+		t.Errorf("want: 2; got: %s", v)
+	}
+	if v, _ := p.Get("B"); v != "1" {
+		t.Errorf("want: 1; got: %s", v)
+	}
+}
@@ -6,71 +6,414 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// pbkdf2SaltSize is the number of random salt bytes for algorithms that
+	// use PBKDF2
+	pbkdf2SaltSize = 16
+	// pbkdf2MaxIterations limits the work done to decrypt a single value so
+	// that a crafted value can not stall a decrypt
+	pbkdf2MaxIterations = 1_000_000
+)
+
+// pbkdf2Iterations is the number of PBKDF2 iterations used for new values.
+// The count is stored in each value so it can be changed without affecting
+// existing values.
+var pbkdf2Iterations = 600_000
+
+// Cipher provides encryption and decryption of property values for an
+// algorithm. Implementations are registered with RegisterCipher and are
+// selected by the algorithm id marker at the start of each value.
+//
+// The marker and base64 encoding are handled by Encrypt and Decrypt, so a
+// Cipher only works with raw bytes. Any parameters needed for decryption,
+// such as a nonce or salt, must be included in the encrypted bytes.
+type Cipher interface {
+	// Encrypt returns the encrypted form of the plaintext using the password.
+	Encrypt(password string, plaintext []byte) ([]byte, error)
+
+	// Decrypt returns the plaintext of a value produced by Encrypt using the
+	// password. An error must be returned if the value cannot be
+	// authenticated.
+	Decrypt(password string, ciphertext []byte) ([]byte, error)
+}
+
+var (
+	// ciphersMu protects ciphers
+	ciphersMu sync.RWMutex
+	// ciphers holds the registered algorithms by id
+	ciphers = map[string]Cipher{
+		EncryptAESGCM:         builtinCipher{alg: aesGCM{}},
+		EncryptAESGCMPBKDF2:   builtinCipher{alg: pbkdf2Cipher{newAEAD: newAESGCM}},
+		EncryptXChaCha20:      builtinCipher{alg: pbkdf2Cipher{newAEAD: chacha20poly1305.NewX}},
+		EncryptAESGCMBound:    builtinCipher{alg: pbkdf2Cipher{newAEAD: newAESGCM}, bound: true},
+		EncryptXChaCha20Bound: builtinCipher{alg: pbkdf2Cipher{newAEAD: chacha20poly1305.NewX}, bound: true},
+		EncryptX25519:         builtinCipher{alg: x25519Cipher{}},
+		EncryptX25519Bound:    builtinCipher{alg: x25519Cipher{}, bound: true},
+		EncryptAESGCMKeyBound: builtinCipher{alg: aesGCM{}, bound: true},
+	}
 )
 
+// RegisterCipher makes an encryption algorithm available to Encrypt and
+// Decrypt using the provided id. The id must be in the marker format used for
+// values, such as "[enc:9]" or "[enc:mykms]", and may not contain ':' since
+// it separates the algorithm from a key ID (see Keyring).
+//
+// RegisterCipher panics if the id is not a valid marker, is already
+// registered, or if c is nil; it is intended to be called during program
+// initialization.
+func RegisterCipher(id string, c Cipher) {
+	if c == nil {
+		panic("props: RegisterCipher cipher is nil")
+	}
+	if !strings.HasPrefix(id, "[enc:") || !strings.HasSuffix(id, "]") || len(id) == len("[enc:]") ||
+		strings.Count(id, "]") != 1 || strings.Count(id, ":") != 1 || id == EncryptNone {
+		panic("props: RegisterCipher invalid id " + id)
+	}
+
+	ciphersMu.Lock()
+	defer ciphersMu.Unlock()
+	if _, dup := ciphers[id]; dup {
+		panic("props: RegisterCipher called twice for " + id)
+	}
+	ciphers[id] = c
+}
+
+// Ciphers returns the sorted ids of all registered encryption algorithms.
+func Ciphers() []string {
+	ciphersMu.RLock()
+	defer ciphersMu.RUnlock()
+	ids := make([]string, 0, len(ciphers))
+	for id := range ciphers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// lookupCipher returns the cipher registered for the id.
+func lookupCipher(id string) (Cipher, bool) {
+	ciphersMu.RLock()
+	defer ciphersMu.RUnlock()
+	c, ok := ciphers[id]
+	return c, ok
+}
+
+// BoundCipher is a Cipher that authenticates additional data, such as the
+// property key, along with the value. A value encrypted with additional data
+// can only be decrypted with the same data, which prevents an encrypted value
+// from being copied to another property.
+//
+// EncryptBound and DecryptBound use the BoundCipher methods when the algorithm
+// implements them. An algorithm that requires additional data should return
+// an error from Encrypt and Decrypt; one that does not may ignore the data.
+type BoundCipher interface {
+	Cipher
+
+	// EncryptBound returns the encrypted form of the plaintext using the
+	// password and authenticating the data.
+	EncryptBound(password string, plaintext, data []byte) ([]byte, error)
+
+	// DecryptBound returns the plaintext of a value produced by EncryptBound
+	// using the password. An error must be returned if the value or the data
+	// cannot be authenticated.
+	DecryptBound(password string, ciphertext, data []byte) ([]byte, error)
+}
+
 // Decrypt returns the plaintext value of a property encrypted with the Encrypt
 // function. If the property does not exist, then the default value will be
 // returned with a nil error. If the property value could not be decrypted,
 // then an error and the default value will be returned.
+//
+// Values encrypted with EncryptBound using a bound algorithm can not be
+// decrypted by Decrypt; use DecryptBound instead. Any key ID in the value is
+// ignored; use a Keyring to select the key by ID.
 func Decrypt(password string, val string) (string, error) {
+	dec, err := decrypt(password, val, nil)
+	return string(dec), err
+}
+
+// DecryptBound returns the plaintext value of a property encrypted with the
+// EncryptBound function using the same property key and context. Values
+// encrypted with an algorithm that does not support binding are decrypted as
+// with Decrypt.
+func DecryptBound(password, val, key string, context ...string) (string, error) {
+	dec, err := decrypt(password, val, boundData(key, context))
+	return string(dec), err
+}
+
+// decrypt returns the plaintext of the value. If data is nil, the value must
+// not use a bound algorithm.
+func decrypt(password, val string, data []byte) ([]byte, error) {
+	alg, _, enc, err := decodeValue(val)
+	if err != nil || alg == EncryptNone {
+		return enc, err
+	}
+	c, _ := lookupCipher(alg)
+	var dec []byte
+	if bc, ok := c.(BoundCipher); ok && data != nil {
+		dec, err = bc.DecryptBound(password, enc, data)
+	} else {
+		dec, err = c.Decrypt(password, enc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// decodeValue splits an encrypted value into its algorithm marker, key ID,
+// and the decoded bytes. For EncryptNone, the plaintext is returned.
+func decodeValue(val string) (string, string, []byte, error) {
 	if !strings.Contains(val, "]") {
-		return "", fmt.Errorf("missing algorithm")
+		return "", "", nil, fmt.Errorf("missing algorithm")
 	}
-	alg := val[0 : strings.Index(val, "]")+1]
+	marker := val[0 : strings.Index(val, "]")+1]
+	alg, keyID := parseMarker(marker)
 	if alg == EncryptNone {
-		return val[len(alg):], nil
+		return alg, "", []byte(val[len(marker):]), nil
 	}
 
-	enc, err := base64.URLEncoding.DecodeString(val[len(alg):])
+	if _, ok := lookupCipher(alg); !ok {
+		return "", "", nil, fmt.Errorf("unknown algorithm")
+	}
+	enc, err := base64.URLEncoding.DecodeString(val[len(marker):])
 	if err != nil {
-		return "", err
+		return "", "", nil, err
 	}
-	switch alg {
-	case EncryptAESGCM:
-		block, err := aes.NewCipher([]byte(password))
-		if err != nil {
-			return "", err
-		}
-		// AES guarantees correct block size
-		gcm, _ := cipher.NewGCM(block)
-		nonceSize := gcm.NonceSize()
-		if len(enc) < nonceSize+1 {
-			return "", fmt.Errorf("encrypted value too small")
-		}
-		nonce, enc2 := enc[:nonceSize], enc[nonceSize:]
-		dec, err := gcm.Open(nil, nonce, enc2, nil)
-		if err != nil {
-			return "", err
-		}
-		return string(dec), nil
-	default:
-		return "", fmt.Errorf("unknown algorithm")
+	return alg, keyID, enc, nil
+}
+
+// parseMarker splits a marker such as "[enc:2:k2024]" into the algorithm id
+// "[enc:2]" and the key ID "k2024". If there is no key ID, the marker is
+// returned unchanged with an empty key ID.
+func parseMarker(marker string) (string, string) {
+	if !strings.HasPrefix(marker, "[enc:") {
+		return marker, ""
+	}
+	inner := marker[len("[enc:") : len(marker)-1]
+	if i := strings.IndexByte(inner, ':'); i >= 0 {
+		return "[enc:" + inner[:i] + "]", inner[i+1:]
 	}
+	return marker, ""
 }
 
 // Encrypt returns the value encrypted with the provided algorithm in base64
-// format. If encryption fails, an empty string and error are returned.
+// format. The algorithm may be EncryptNone or any id registered with
+// RegisterCipher. If encryption fails, an empty string and error are returned.
+//
+// For EncryptAESGCMPBKDF2 and EncryptXChaCha20, the password may be any
+// length. The PBKDF2 iteration count and random salt are stored at the start
+// of the encrypted value so that it can be decrypted with only the password.
+//
+// Bound algorithms, such as EncryptAESGCMBound, require EncryptBound.
 func Encrypt(alg, password, value string) (string, error) {
-	switch alg {
-	case EncryptNone:
+	return encrypt(alg, "", password, value, nil)
+}
+
+// EncryptBound returns the value encrypted with the provided algorithm in
+// base64 format. If the algorithm implements BoundCipher, such as
+// EncryptAESGCMBound, the encrypted value is bound to the property key and any
+// additional context, such as a file or profile name. The value can then only
+// be decrypted by DecryptBound with the same key and context. Other
+// algorithms encrypt the value as with Encrypt.
+func EncryptBound(alg, password, value, key string, context ...string) (string, error) {
+	return encrypt(alg, "", password, value, boundData(key, context))
+}
+
+// encrypt returns the encrypted value with the key ID, if any, added to the
+// marker. If data is nil, the value is not bound.
+func encrypt(alg, keyID, password, value string, data []byte) (string, error) {
+	if alg == EncryptNone {
 		return EncryptNone + value, nil
-	case EncryptAESGCM:
-		block, err := aes.NewCipher([]byte(password))
-		if err != nil {
-			return "", fmt.Errorf("unable to init aes encryption [%w]", err)
-		}
-		// AES guarantees correct block size
-		gcm, _ := cipher.NewGCM(block)
-		nonce := make([]byte, gcm.NonceSize())
-		rand.Read(nonce)
-
-		enc := gcm.Seal(nonce, nonce, []byte(value), nil)
-		result := base64.URLEncoding.EncodeToString(enc)
-		return EncryptAESGCM + result, nil
-	default:
+	}
+
+	c, ok := lookupCipher(alg)
+	if !ok {
 		return "", fmt.Errorf("unknown algorithm %s", alg)
 	}
+	var enc []byte
+	var err error
+	if bc, ok := c.(BoundCipher); ok && data != nil {
+		enc, err = bc.EncryptBound(password, []byte(value), data)
+	} else {
+		enc, err = c.Encrypt(password, []byte(value))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	marker := alg
+	if keyID != "" {
+		marker = alg[:len(alg)-1] + ":" + keyID + "]"
+	}
+	return marker + base64.URLEncoding.EncodeToString(enc), nil
+}
+
+// boundData encodes the property key and context as additional data. Each
+// part is prefixed by its length so that different parts can not produce the
+// same data.
+func boundData(key string, context []string) []byte {
+	size := 4 + len(key)
+	for _, c := range context {
+		size += 4 + len(c)
+	}
+	data := make([]byte, 0, size)
+	for _, part := range append([]string{key}, context...) {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(part)))
+		data = append(data, part...)
+	}
+	return data
+}
+
+// dataCipher is implemented by the built-in algorithms, which encrypt with
+// optional additional data.
+type dataCipher interface {
+	encrypt(password string, plaintext, data []byte) ([]byte, error)
+	decrypt(password string, ciphertext, data []byte) ([]byte, error)
+}
+
+// builtinCipher adapts a dataCipher to BoundCipher. If bound is set, values
+// must be encrypted and decrypted with additional data; otherwise the data is
+// ignored so the algorithm can be used with Encrypt or EncryptBound.
+type builtinCipher struct {
+	alg dataCipher
+
+	// bound indicates that additional data is required
+	bound bool
+}
+
+func (c builtinCipher) Encrypt(password string, plaintext []byte) ([]byte, error) {
+	if c.bound {
+		return nil, fmt.Errorf("algorithm requires a property key; use EncryptBound")
+	}
+	return c.alg.encrypt(password, plaintext, nil)
+}
+
+func (c builtinCipher) Decrypt(password string, ciphertext []byte) ([]byte, error) {
+	if c.bound {
+		return nil, fmt.Errorf("algorithm requires a property key; use DecryptBound")
+	}
+	return c.alg.decrypt(password, ciphertext, nil)
+}
+
+func (c builtinCipher) EncryptBound(password string, plaintext, data []byte) ([]byte, error) {
+	if !c.bound {
+		data = nil
+	}
+	return c.alg.encrypt(password, plaintext, data)
+}
+
+func (c builtinCipher) DecryptBound(password string, ciphertext, data []byte) ([]byte, error) {
+	if !c.bound {
+		data = nil
+	}
+	return c.alg.decrypt(password, ciphertext, data)
+}
+
+// aesGCM implements EncryptAESGCM and EncryptAESGCMKeyBound using the password
+// as the key.
+type aesGCM struct{}
+
+// encrypt encrypts the plaintext and additional data using the password as
+// the key.
+func (aesGCM) encrypt(password string, plaintext, data []byte) ([]byte, error) {
+	aead, err := newAESGCM([]byte(password))
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, nil, plaintext, data), nil
+}
+
+// decrypt decrypts a value produced by encrypt with the additional data.
+func (aesGCM) decrypt(password string, ciphertext, data []byte) ([]byte, error) {
+	aead, err := newAESGCM([]byte(password))
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext, data)
+}
+
+// pbkdf2Cipher implements algorithms that derive a 256-bit key from the
+// password with PBKDF2-SHA256. The encrypted value starts with the iteration
+// count (4 bytes, big endian) and the salt.
+type pbkdf2Cipher struct {
+	// newAEAD creates the cipher for a derived key
+	newAEAD func(key []byte) (cipher.AEAD, error)
+}
+
+// encrypt derives the key and encrypts the plaintext with the additional data.
+func (c pbkdf2Cipher) encrypt(password string, plaintext, data []byte) ([]byte, error) {
+	header := make([]byte, 4+pbkdf2SaltSize)
+	binary.BigEndian.PutUint32(header, uint32(pbkdf2Iterations))
+	salt := header[4:]
+	rand.Read(salt)
+	key := pbkdf2.Key([]byte(password), salt, pbkdf2Iterations, 32, sha256.New)
+
+	aead, err := c.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, header, plaintext, data), nil
+}
+
+// decrypt derives the key and decrypts the value with the additional data.
+func (c pbkdf2Cipher) decrypt(password string, ciphertext, data []byte) ([]byte, error) {
+	if len(ciphertext) < 4+pbkdf2SaltSize {
+		return nil, fmt.Errorf("encrypted value too small")
+	}
+	iter := binary.BigEndian.Uint32(ciphertext)
+	if iter == 0 || iter > pbkdf2MaxIterations {
+		return nil, fmt.Errorf("invalid iteration count %d", iter)
+	}
+	salt := ciphertext[4 : 4+pbkdf2SaltSize]
+	key := pbkdf2.Key([]byte(password), salt, int(iter), 32, sha256.New)
+
+	aead, err := c.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext[4+pbkdf2SaltSize:], data)
+}
+
+// newAESGCM creates an AES-GCM cipher with the key.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to init aes encryption [%w]", err)
+	}
+	// AES guarantees correct block size
+	gcm, _ := cipher.NewGCM(block)
+	return gcm, nil
+}
+
+// seal encrypts the value and additional data using a random nonce. The nonce
+// and encrypted value are appended to dst.
+func seal(aead cipher.AEAD, dst, value, data []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, value, data)
+}
+
+// open decrypts a value produced by seal (without any prefix in dst).
+func open(aead cipher.AEAD, enc, data []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(enc) < nonceSize+1 {
+		return nil, fmt.Errorf("encrypted value too small")
+	}
+	nonce, enc2 := enc[:nonceSize], enc[nonceSize:]
+	return aead.Open(nil, nonce, enc2, data)
 }
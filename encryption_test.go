@@ -3,6 +3,8 @@
 package props
 
 import (
+	"encoding/base64"
+	"errors"
 	"strings"
 	"testing"
 )
@@ -84,3 +86,235 @@ func TestDecrypt(t *testing.T) {
 		t.Errorf("want: '', err != nil; got: %s, %v", val, err)
 	}
 }
+
+func TestEncryptPBKDF2(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	for _, pass := range []string{"a", "short password", "1234567890123456", strings.Repeat("x", 100)} {
+		val, err := Encrypt(EncryptAESGCMPBKDF2, pass, "plaintext")
+		if !strings.HasPrefix(val, "[enc:2]") || err != nil {
+			t.Fatalf("want: '[enc:2]...', err == nil; got: %s, %v", val, err)
+		}
+		val2, _ := Encrypt(EncryptAESGCMPBKDF2, pass, "plaintext")
+		if val == val2 {
+			t.Errorf("want: different salt and nonce; got: same value %s", val)
+		}
+
+		dec, err := Decrypt(pass, val)
+		if dec != "plaintext" || err != nil {
+			t.Errorf("want: 'plaintext', err == nil; got: %s, %v", dec, err)
+		}
+		dec, err = Decrypt(pass+"x", val)
+		if dec != "" || err == nil {
+			t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+		}
+	}
+}
+
+func TestDecryptPBKDF2(t *testing.T) {
+	enc := "[enc:2]AAAD6OOM4_7pcoxXANgn--t3Z23PDlDxlHRlukohz27USy12f22owgH1n9PCavHT8pbo-UvxprpT"
+	val, err := Decrypt("pass", enc)
+	if val != "plaintext" || err != nil {
+		t.Errorf("want: 'plaintext', err == nil; got: %s, %v", val, err)
+	}
+
+	bad := []string{
+		"[enc:2]",
+		"[enc:2]AAAD6OOM4_7pcoxXANgn",
+		// zero iterations
+		"[enc:2]AAAAAOOM4_7pcoxXANgn--t3Z23PDlDxlHRlukohz27USy12f22owgH1n9PCavHT8pbo-UvxprpT",
+		// too many iterations
+		"[enc:2]_____-OM4_7pcoxXANgn--t3Z23PDlDxlHRlukohz27USy12f22owgH1n9PCavHT8pbo-UvxprpT",
+		// changed iterations
+		"[enc:2]AAAD6eOM4_7pcoxXANgn--t3Z23PDlDxlHRlukohz27USy12f22owgH1n9PCavHT8pbo-UvxprpT",
+		// missing ciphertext
+		"[enc:2]AAAD6OOM4_7pcoxXANgn--t3Z23PDlDxlHRl",
+	}
+	for i, test := range bad {
+		val, err := Decrypt("pass", test)
+		if val != "" || err == nil {
+			t.Errorf("%d want: '', err != nil; got: %s, %v", i, val, err)
+		}
+	}
+}
+
+// xorCipher is a test cipher that xors the plaintext with the password.
+type xorCipher struct{}
+
+func (xorCipher) Encrypt(password string, plaintext []byte) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("password required")
+	}
+	out := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		out[i] = b ^ password[i%len(password)]
+	}
+	return out, nil
+}
+
+func (c xorCipher) Decrypt(password string, ciphertext []byte) ([]byte, error) {
+	return c.Encrypt(password, ciphertext)
+}
+
+// unregisterCipher removes a cipher added by a test so that the test can be
+// run more than once.
+func unregisterCipher(id string) {
+	ciphersMu.Lock()
+	defer ciphersMu.Unlock()
+	delete(ciphers, id)
+}
+
+func TestRegisterCipher(t *testing.T) {
+	RegisterCipher("[enc:xor-test]", xorCipher{})
+	defer unregisterCipher("[enc:xor-test]")
+
+	found := false
+	for _, id := range Ciphers() {
+		found = found || id == "[enc:xor-test]"
+	}
+	if !found {
+		t.Errorf("want: [enc:xor-test] in %v; got: none", Ciphers())
+	}
+
+	enc, err := Encrypt("[enc:xor-test]", "key", "plaintext")
+	if !strings.HasPrefix(enc, "[enc:xor-test]") || err != nil {
+		t.Fatalf("want: '[enc:xor-test]...', err == nil; got: %s, %v", enc, err)
+	}
+	dec, err := Decrypt("key", enc)
+	if dec != "plaintext" || err != nil {
+		t.Errorf("want: 'plaintext', err == nil; got: %s, %v", dec, err)
+	}
+
+	enc, err = Encrypt("[enc:xor-test]", "", "plaintext")
+	if enc != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", enc, err)
+	}
+	dec, err = Decrypt("", "[enc:xor-test]AAAA")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+
+	panics := []struct {
+		id string
+		c  Cipher
+	}{
+		{"[enc:xor-test]", xorCipher{}},
+		{"[enc:nil]", nil},
+		{"[enc:0]", xorCipher{}},
+		{"[enc:]", xorCipher{}},
+		{"enc:5", xorCipher{}},
+		{"[enc:5]]", xorCipher{}},
+		{"[enc:5:key]", xorCipher{}},
+	}
+	for _, test := range panics {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s want: panic; got: none", test.id)
+				}
+			}()
+			RegisterCipher(test.id, test.c)
+		}()
+	}
+}
+
+func TestEncryptXChaCha20(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	val, err := Encrypt(EncryptXChaCha20, "any password", "plaintext")
+	if !strings.HasPrefix(val, "[enc:3]") || err != nil {
+		t.Fatalf("want: '[enc:3]...', err == nil; got: %s, %v", val, err)
+	}
+	// iterations, salt, 24 byte nonce, ciphertext, and tag
+	if enc, _ := base64.URLEncoding.DecodeString(val[7:]); len(enc) != 4+16+24+9+16 {
+		t.Errorf("want: %d bytes; got: %d", 4+16+24+9+16, len(enc))
+	}
+
+	dec, err := Decrypt("any password", val)
+	if dec != "plaintext" || err != nil {
+		t.Errorf("want: 'plaintext', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = Decrypt("other password", val)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+
+	// a value from the AES algorithm must not decrypt as XChaCha20
+	aes, _ := Encrypt(EncryptAESGCMPBKDF2, "any password", "plaintext")
+	dec, err = Decrypt("any password", EncryptXChaCha20+aes[7:])
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+}
+
+func TestEncryptBound(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	for _, alg := range []string{EncryptAESGCMBound, EncryptXChaCha20Bound} {
+		val, err := EncryptBound(alg, "pass", "plaintext", "db.password", "prod")
+		if !strings.HasPrefix(val, alg) || err != nil {
+			t.Fatalf("want: '%s...', err == nil; got: %s, %v", alg, val, err)
+		}
+
+		dec, err := DecryptBound("pass", val, "db.password", "prod")
+		if dec != "plaintext" || err != nil {
+			t.Errorf("%s want: 'plaintext', err == nil; got: %s, %v", alg, dec, err)
+		}
+
+		bad := [][]string{
+			{"admin.password", "prod"},
+			{"db.password"},
+			{"db.password", "dev"},
+			{"db.password", "prod", "extra"},
+			{"db.passwordprod"},
+			{"db.password", "pr", "od"},
+		}
+		for _, test := range bad {
+			dec, err = DecryptBound("pass", val, test[0], test[1:]...)
+			if dec != "" || err == nil {
+				t.Errorf("%s %v want: '', err != nil; got: %s, %v", alg, test, dec, err)
+			}
+		}
+
+		dec, err = Decrypt("pass", val)
+		if dec != "" || err == nil {
+			t.Errorf("%s want: '', err != nil; got: %s, %v", alg, dec, err)
+		}
+		val, err = Encrypt(alg, "pass", "plaintext")
+		if val != "" || err == nil {
+			t.Errorf("%s want: '', err != nil; got: %s, %v", alg, val, err)
+		}
+	}
+
+	// unbound algorithms ignore the key
+	val, err := EncryptBound(EncryptAESGCMPBKDF2, "pass", "plaintext", "db.password")
+	if !strings.HasPrefix(val, EncryptAESGCMPBKDF2) || err != nil {
+		t.Fatalf("want: '[enc:2]...', err == nil; got: %s, %v", val, err)
+	}
+	for _, key := range []string{"db.password", "other"} {
+		dec, err := DecryptBound("pass", val, key)
+		if dec != "plaintext" || err != nil {
+			t.Errorf("want: 'plaintext', err == nil; got: %s, %v", dec, err)
+		}
+	}
+
+	val, err = EncryptBound(EncryptNone, "pass", "plaintext", "key")
+	if val != "[enc:0]plaintext" || err != nil {
+		t.Errorf("want: '[enc:0]plaintext', err == nil; got: %s, %v", val, err)
+	}
+	dec, err := DecryptBound("pass", val, "key")
+	if dec != "plaintext" || err != nil {
+		t.Errorf("want: 'plaintext', err == nil; got: %s, %v", dec, err)
+	}
+	val, err = EncryptBound("[enc:unknown]", "pass", "plaintext", "key")
+	if val != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", val, err)
+	}
+	dec, err = DecryptBound("pass", "[enc:unknown]AAAA", "key")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+}
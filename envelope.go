@@ -0,0 +1,214 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// DataKeyHeader starts the comment line that holds a file's wrapped data
+	// key, as in "#[datakey:dk-1a2b3c4d][enc:2:k2025]<base64 data>".
+	DataKeyHeader = "#[datakey:"
+
+	// dataKeySize is the size of a data key in bytes (AES-256)
+	dataKeySize = 32
+)
+
+// DataKey is a random key used to encrypt the values in a single file
+// (envelope encryption). The data key is stored in a header comment in the
+// file, encrypted by a master key, so rotating the master key only rewrites
+// the header. Values are encrypted with EncryptAESGCMKeyBound using the data
+// key, bound to their property key, and carry the data key's ID in their
+// marker, such as "[enc:8:dk-1a2b3c4d]".
+//
+// DataKey implements KeyProvider. Values that were not encrypted with the data
+// key are decrypted with the master key that unwrapped it, so a DataKey only
+// decrypts the values of its own file. Use DataKeys for a Configuration that
+// is loaded from several files.
+type DataKey struct {
+	// ID identifies the data key in value markers.
+	ID string
+
+	key    string
+	master KeyProvider
+}
+
+// Ensure that DataKey implements KeyProvider
+var _ KeyProvider = &DataKey{}
+
+// NewDataKey returns a new random data key with a random ID.
+func NewDataKey() (*DataKey, error) {
+	id := make([]byte, 4)
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &DataKey{ID: "dk-" + hex.EncodeToString(id), key: string(key)}, nil
+}
+
+// IsDataKeyHeader determines whether the line is a data key header.
+func IsDataKeyHeader(line string) bool {
+	return strings.HasPrefix(line, DataKeyHeader)
+}
+
+// Header returns the header comment line for the data key, wrapped with the
+// primary key of the master provider using the algorithm. The wrapped key is
+// bound to the data key's ID when a bound algorithm is used.
+func (d *DataKey) Header(master KeyProvider, alg string) (string, error) {
+	wrapped, err := EncryptBoundWith(master, alg, base64.URLEncoding.EncodeToString([]byte(d.key)), d.ID)
+	if err != nil {
+		return "", fmt.Errorf("unable to wrap data key [%w]", err)
+	}
+	return DataKeyHeader + d.ID + "]" + wrapped, nil
+}
+
+// ParseDataKey returns the data key from a header line created by Header,
+// unwrapping it with the master provider.
+func ParseDataKey(master KeyProvider, header string) (*DataKey, error) {
+	if !IsDataKeyHeader(header) {
+		return nil, fmt.Errorf("missing data key header")
+	}
+	id, wrapped, ok := strings.Cut(header[len(DataKeyHeader):], "]")
+	if !ok || !isKeyID(id) {
+		return nil, fmt.Errorf("invalid data key id")
+	}
+	encoded, err := DecryptBoundWith(master, strings.TrimSpace(wrapped), id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key %s [%w]", id, err)
+	}
+	key, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil || len(key) != dataKeySize {
+		return nil, fmt.Errorf("invalid data key %s", id)
+	}
+	return &DataKey{ID: id, key: string(key), master: master}, nil
+}
+
+// FindDataKey reads lines from r until it finds a data key header and returns
+// the unwrapped data key. If there is no header, nil is returned with a nil
+// error.
+func FindDataKey(master KeyProvider, r io.Reader) (*DataKey, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); IsDataKeyHeader(line) {
+			return ParseDataKey(master, line)
+		}
+	}
+	return nil, scanner.Err()
+}
+
+// PrimaryKey returns the ID and the data key.
+func (d *DataKey) PrimaryKey() (string, string, error) {
+	return d.ID, d.key, nil
+}
+
+// Key returns the data key if the id matches. Other keys are requested from
+// the master provider, if any.
+func (d *DataKey) Key(id string) (string, error) {
+	if id == d.ID {
+		return d.key, nil
+	}
+	if d.master == nil {
+		return "", fmt.Errorf("unknown key id %s", id)
+	}
+	return d.master.Key(id)
+}
+
+// Encrypt returns the value encrypted with the data key and bound to the
+// property key and context. See EncryptBound for details.
+func (d *DataKey) Encrypt(value, key string, context ...string) (string, error) {
+	return EncryptBoundWith(d, EncryptAESGCMKeyBound, value, key, context...)
+}
+
+// Decrypt returns the plaintext of a value encrypted with the data key or the
+// master key using the same property key and context. See DecryptBound for
+// details.
+func (d *DataKey) Decrypt(val, key string, context ...string) (string, error) {
+	return DecryptBoundWith(d, val, key, context...)
+}
+
+// DataKeys provides the data keys of several files so that a Configuration
+// loaded from several envelope encrypted files can decrypt all of their
+// values. Data keys are added with Add or Find and are unwrapped with the
+// master provider. Other keys, including the primary key, are provided by the
+// master provider. DataKeys is safe for concurrent use.
+type DataKeys struct {
+	// Master provides the keys used to unwrap the data keys and to decrypt
+	// values that were not encrypted with a data key.
+	Master KeyProvider
+
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+// Ensure that DataKeys implements KeyProvider
+var _ KeyProvider = &DataKeys{}
+
+// Add unwraps the data key in a header line created by DataKey.Header and
+// adds it. An error is returned if a different data key with the same ID was
+// already added.
+func (d *DataKeys) Add(header string) (*DataKey, error) {
+	dk, err := ParseDataKey(d.Master, header)
+	if err != nil {
+		return nil, err
+	}
+	return dk, d.add(dk)
+}
+
+// Find reads lines from r until it finds a data key header and adds the
+// unwrapped data key as with Add. If there is no header, nil is returned with
+// a nil error.
+func (d *DataKeys) Find(r io.Reader) (*DataKey, error) {
+	dk, err := FindDataKey(d.Master, r)
+	if dk == nil || err != nil {
+		return nil, err
+	}
+	return dk, d.add(dk)
+}
+
+// add stores the data key by its ID.
+func (d *DataKeys) add(dk *DataKey) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key, ok := d.keys[dk.ID]; ok && key != dk.key {
+		return fmt.Errorf("duplicate data key id %s", dk.ID)
+	}
+	if d.keys == nil {
+		d.keys = make(map[string]string)
+	}
+	d.keys[dk.ID] = dk.key
+	return nil
+}
+
+// PrimaryKey returns the primary key of the master provider.
+func (d *DataKeys) PrimaryKey() (string, string, error) {
+	if d.Master == nil {
+		return "", "", fmt.Errorf("missing master key")
+	}
+	return d.Master.PrimaryKey()
+}
+
+// Key returns the data key with the id if it was added. Other keys are
+// requested from the master provider, if any.
+func (d *DataKeys) Key(id string) (string, error) {
+	d.mu.RLock()
+	key, ok := d.keys[id]
+	d.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if d.Master == nil {
+		return "", fmt.Errorf("unknown key id %s", id)
+	}
+	return d.Master.Key(id)
+}
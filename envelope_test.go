@@ -0,0 +1,209 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDataKey(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	old := &Keyring{Primary: "m1", Keys: map[string]string{"m1": "master one"}}
+	dk, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := dk.Header(old, EncryptAESGCMBound)
+	if !strings.HasPrefix(header, DataKeyHeader+dk.ID+"][enc:4:m1]") || err != nil {
+		t.Fatalf("want: '%s%s][enc:4:m1]...', err == nil; got: %s, %v", DataKeyHeader, dk.ID, header, err)
+	}
+	val, err := dk.Encrypt("secret", "db.password", "default")
+	if !strings.HasPrefix(val, "[enc:8:"+dk.ID+"]") || err != nil {
+		t.Fatalf("want: '[enc:8:%s]...', err == nil; got: %s, %v", dk.ID, val, err)
+	}
+	direct, _ := old.Encrypt(EncryptAESGCMPBKDF2, "direct")
+
+	file := header + "\n" + "db.password=" + val + "\n" + "api.key=" + direct + "\n"
+	p, err := Read(strings.NewReader(file))
+	if err != nil || len(p.Names()) != 2 {
+		t.Fatalf("want: 2 properties, err == nil; got: %v, %v", p.Names(), err)
+	}
+
+	found, err := FindDataKey(old, strings.NewReader(file))
+	if found == nil || found.ID != dk.ID || err != nil {
+		t.Fatalf("want: %s, err == nil; got: %v, %v", dk.ID, found, err)
+	}
+	c := &Configuration{Props: p, BindContext: []string{"default"}}
+	for key, want := range map[string]string{"db.password": "secret", "api.key": "direct"} {
+		dec, err := c.DecryptWith(found, key, "default")
+		if dec != want || err != nil {
+			t.Errorf("%s want: '%s', err == nil; got: %s, %v", key, want, dec, err)
+		}
+	}
+
+	// rotating the master key only changes the header
+	rotated := &Keyring{Primary: "m2", Keys: map[string]string{"m2": "master two"}}
+	header2, err := found.Header(rotated, EncryptXChaCha20)
+	if !strings.HasPrefix(header2, DataKeyHeader+dk.ID+"][enc:3:m2]") || err != nil {
+		t.Fatalf("want: '%s%s][enc:3:m2]...', err == nil; got: %s, %v", DataKeyHeader, dk.ID, header2, err)
+	}
+	found, err = ParseDataKey(rotated, header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := found.Decrypt(val, "db.password", "default")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+
+	found, err = FindDataKey(old, strings.NewReader("a=b\n"))
+	if found != nil || err != nil {
+		t.Errorf("want: nil, err == nil; got: %v, %v", found, err)
+	}
+}
+
+func TestDataKeyBad(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	master := &Keyring{Primary: "m1", Keys: map[string]string{"m1": "master one"}}
+	dk, _ := NewDataKey()
+	header, _ := dk.Header(master, EncryptAESGCMBound)
+	wrapped := header[strings.Index(header, "]")+1:]
+	short, _ := EncryptBoundWith(master, EncryptAESGCMBound, "c2hvcnQ=", "dk-1")
+
+	bad := []string{
+		"# comment",
+		DataKeyHeader + "dk-1",
+		DataKeyHeader + "bad id]" + wrapped,
+		DataKeyHeader + "dk-other]" + wrapped,
+		DataKeyHeader + dk.ID + "][enc:4:m2]" + wrapped[len("[enc:4:m1]"):],
+		DataKeyHeader + "dk-1]" + short,
+	}
+	for _, line := range bad {
+		found, err := ParseDataKey(master, line)
+		if found != nil || err == nil {
+			t.Errorf("%s want: nil, err != nil; got: %v, %v", line, found, err)
+		}
+	}
+
+	_, err := FindDataKey(&Keyring{Primary: "m1", Keys: map[string]string{"m1": "wrong"}}, strings.NewReader("a=b\n"+header+"\n"))
+	if err == nil {
+		t.Errorf("want: err != nil for wrong master key; got: nil")
+	}
+
+	dec, err := dk.Decrypt("[enc:2:m1]AAAA", "a")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil without master key; got: %s, %v", dec, err)
+	}
+}
+
+func TestDataKeyBound(t *testing.T) {
+	dk, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := dk.Encrypt("secret", "db.password", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a value swapped to another key or context must not decrypt
+	p, _ := Read(strings.NewReader("db.password=" + val + "\nadmin.password=" + val + "\n"))
+	c := &Configuration{Props: p, BindContext: []string{"prod"}}
+	dec, err := c.DecryptWith(dk, "db.password", "")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = c.DecryptWith(dk, "admin.password", "default")
+	if dec != "default" || err == nil {
+		t.Errorf("want: 'default', err != nil for swapped value; got: %s, %v", dec, err)
+	}
+	dec, err = dk.Decrypt(val, "db.password", "dev")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil for other context; got: %s, %v", dec, err)
+	}
+	dec, err = dk.Decrypt(val, "")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil without property key; got: %s, %v", dec, err)
+	}
+	dec, err = DecryptWith(dk, val)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil for unbound decrypt; got: %s, %v", dec, err)
+	}
+
+	// values from older versions are not bound
+	old, _ := EncryptWith(dk, EncryptAESGCM, "secret")
+	dec, err = dk.Decrypt(old, "db.password", "prod")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil for [enc:1] value; got: %s, %v", dec, err)
+	}
+}
+
+func TestDataKeys(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	master := &Keyring{Primary: "m1", Keys: map[string]string{"m1": "master one"}}
+	file := func(key, value string) (*DataKey, string) {
+		dk, _ := NewDataKey()
+		header, _ := dk.Header(master, EncryptAESGCMBound)
+		val, _ := dk.Encrypt(value, key)
+		return dk, header + "\n" + key + "=" + val + "\n"
+	}
+	dk1, file1 := file("db.password", "one")
+	dk2, file2 := file("api.key", "two")
+	direct, _ := master.EncryptBound(EncryptAESGCMBound, "three", "smtp.password")
+
+	p1, _ := Read(strings.NewReader(file1))
+	p2, _ := Read(strings.NewReader(file2 + "smtp.password=" + direct + "\n"))
+	c := &Configuration{Props: &Combined{Sources: []PropertyGetter{p1, p2}}}
+
+	// a single data key only decrypts its own file
+	if _, err := c.DecryptWith(dk1, "api.key", ""); err == nil {
+		t.Errorf("want: err != nil for other file's data key; got: nil")
+	}
+
+	keys := &DataKeys{Master: master}
+	for _, f := range []string{file1, file2, "a=b\n"} {
+		if _, err := keys.Find(strings.NewReader(f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, want := range map[string]string{"db.password": "one", "api.key": "two", "smtp.password": "three"} {
+		dec, err := c.DecryptWith(keys, key, "")
+		if dec != want || err != nil {
+			t.Errorf("%s want: '%s', err == nil; got: %s, %v", key, want, dec, err)
+		}
+	}
+
+	id, _, err := keys.PrimaryKey()
+	if id != "m1" || err != nil {
+		t.Errorf("want: m1, err == nil; got: %s, %v", id, err)
+	}
+
+	// adding the same data key again is allowed but a different key is not
+	header := strings.SplitN(file2, "\n", 2)[0]
+	if _, err := keys.Add(header); err != nil {
+		t.Errorf("want: err == nil for same data key; got: %v", err)
+	}
+	other := &DataKey{ID: dk2.ID, key: strings.Repeat("x", dataKeySize)}
+	header, _ = other.Header(master, EncryptAESGCMBound)
+	if _, err := keys.Add(header); err == nil {
+		t.Errorf("want: err != nil for duplicate data key id; got: nil")
+	}
+	if _, err := keys.Add("# comment"); err == nil {
+		t.Errorf("want: err != nil for missing header; got: nil")
+	}
+
+	empty := &DataKeys{}
+	if _, _, err := empty.PrimaryKey(); err == nil {
+		t.Errorf("want: err != nil without master key; got: nil")
+	}
+	if _, err := empty.Key("m1"); err == nil {
+		t.Errorf("want: err != nil without master key; got: nil")
+	}
+}
@@ -7,7 +7,12 @@ import (
 	"strings"
 )
 
-// Environment reads properties from the OS environment.
+// fileSuffix indicates an environment variable that names a file containing
+// the value of another variable.
+const fileSuffix = "_FILE"
+
+// Environment reads properties from the OS environment or from a snapshot of
+// environment variables provided by Vars.
 type Environment struct {
 	// Normalize indicates that key values should be converted to POSIX-style
 	// environment variable names.
@@ -19,21 +24,74 @@ type Environment struct {
 	//
 	// For example, 'foo.bar.baz' would become 'FOO_BAR_BAZ' and
 	// '$my-test#val_1' would become '_MY_TEST_VAL_1'.
+	//
+	// Keys are also matched using the Spring Boot conventions where an
+	// underscore in the key is written as a double underscore '__' and list
+	// indexes are written as numeric names. For example, 'max_conns' would
+	// also match 'MAX__CONNS' and 'servers[0].host' would become
+	// 'SERVERS_0_HOST'.
+	//
+	// If Prefix is also set, Names will return property keys converted back
+	// from the environment variable names using the same conventions, so
+	// 'MAX__CONNS' would be listed as 'max_conns' and 'SERVERS_0_HOST' as
+	// 'servers[0].host'. Environment variables that cannot be retrieved with a
+	// converted key, such as those with lowercase names, are not included.
+	// Without a Prefix, Names returns the environment variable names as is.
 	Normalize bool
+
+	// Prefix limits the environment variables to those that start with the
+	// prefix. It is added to keys passed to Get and GetDefault (after
+	// normalization) and removed from the names returned by Names.
+	//
+	// For example, with a prefix of 'MYAPP_', 'db.host' would become
+	// 'MYAPP_DB_HOST'.
+	Prefix string
+
+	// Vars provides the environment variables to use instead of the OS
+	// environment. If nil, the OS environment is used.
+	Vars map[string]string
+
+	// Files indicates that a variable may be provided by a file named in a
+	// variable with the same name and a '_FILE' suffix, as is common for
+	// secrets in containers. The contents of the file, with leading and
+	// trailing whitespace removed, are used as the value. A variable that is
+	// set directly takes priority over one provided by a file.
+	//
+	// For example, if DB_PASSWORD_FILE=/run/secrets/db then the value of
+	// DB_PASSWORD would be read from /run/secrets/db.
+	Files bool
 }
 
 // Ensure that Environment implements PropertyGetter
 var _ PropertyGetter = &Environment{}
 
+// FromEnviron creates an Environment that reads from a list of variables in
+// "key=value" format, as returned by os.Environ, instead of the OS environment.
+func FromEnviron(env []string) *Environment {
+	vars := make(map[string]string, len(env))
+	for _, v := range env {
+		if i := strings.Index(v, "="); i >= 0 {
+			vars[v[:i]] = v[i+1:]
+		}
+	}
+	return &Environment{Vars: vars}
+}
+
 // Get retrieves the value of a property from the environment. If the env var
 // does not exist, an empty string will be returned. The bool return value
 // indicates whether the property was found.
 func (e *Environment) Get(key string) (string, bool) {
 	if e.Normalize {
-		envKey := strings.Map(normalizeEnv, key)
-		return os.LookupEnv(envKey)
+		envKey := normalizeKey(key)
+		if v, ok := e.lookupEnv(e.Prefix + envKey); ok {
+			return v, true
+		}
+		if legacy := strings.Map(normalizeEnv, key); legacy != envKey {
+			return e.lookupEnv(e.Prefix + legacy)
+		}
+		return "", false
 	} else {
-		return os.LookupEnv(key)
+		return e.lookupEnv(e.Prefix + key)
 	}
 }
 
@@ -47,8 +105,86 @@ func (e *Environment) GetDefault(key, defVal string) string {
 	return v
 }
 
-// Names returns the names of all environment variables.
+// Names returns the names of all environment variables that start with the
+// Prefix with the prefix removed. If both Normalize and Prefix are set, the
+// names are converted to property keys. If Files is set, the names of
+// variables provided by files are included.
 func (e *Environment) Names() []string {
+	env := e.environ()
+	result := make([]string, 0, len(env))
+	if e.Files {
+		vars := make(map[string]struct{}, len(env))
+		for _, name := range env {
+			vars[name] = struct{}{}
+		}
+		for _, name := range env {
+			if !strings.HasSuffix(name, fileSuffix) {
+				continue
+			}
+			name = name[:len(name)-len(fileSuffix)]
+			if _, ok := vars[name]; !ok {
+				vars[name] = struct{}{}
+				env = append(env, name)
+			}
+		}
+	}
+
+	for _, name := range env {
+		if !strings.HasPrefix(name, e.Prefix) || len(name) == len(e.Prefix) {
+			continue
+		}
+		name = name[len(e.Prefix):]
+		if e.Normalize && e.Prefix != "" {
+			key := denormalizeKey(name)
+			if normalizeKey(key) != name {
+				continue
+			}
+			name = key
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// lookupEnv retrieves the value of an environment variable from Vars or the
+// OS environment. If Files is set, the value may also be read from a file.
+func (e *Environment) lookupEnv(name string) (string, bool) {
+	v, ok := e.lookupVar(name)
+	if ok || !e.Files {
+		return v, ok
+	}
+
+	path, ok := e.lookupVar(name + fileSuffix)
+	if !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// lookupVar retrieves the value of a variable from Vars or the OS environment.
+func (e *Environment) lookupVar(name string) (string, bool) {
+	if e.Vars != nil {
+		v, ok := e.Vars[name]
+		return v, ok
+	}
+	return os.LookupEnv(name)
+}
+
+// environ returns the names of all environment variables in Vars or the OS
+// environment.
+func (e *Environment) environ() []string {
+	if e.Vars != nil {
+		names := make([]string, 0, len(e.Vars))
+		for k := range e.Vars {
+			names = append(names, k)
+		}
+		return names
+	}
+
 	env := os.Environ()
 	for i, v := range env {
 		env[i] = v[0:strings.Index(v, "=")]
@@ -69,3 +205,54 @@ func normalizeEnv(r rune) rune {
 		return '_'
 	}
 }
+
+// normalizeKey converts a property key into an environment variable name
+// using the Spring Boot conventions: underscores are doubled, list indexes
+// become numeric names, and other characters are converted by normalizeEnv.
+func normalizeKey(key string) string {
+	var buf strings.Builder
+	for _, r := range key {
+		switch r {
+		case '_':
+			buf.WriteString("__")
+		case '[':
+			buf.WriteRune('_')
+		case ']':
+		default:
+			buf.WriteRune(normalizeEnv(r))
+		}
+	}
+	return buf.String()
+}
+
+// denormalizeKey converts an environment variable name into a property key;
+// this is the reverse of normalizeKey.
+func denormalizeKey(name string) string {
+	segments := make([]string, 0, 4)
+	var seg strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '_' {
+			seg.WriteByte(name[i])
+		} else if i+1 < len(name) && name[i+1] == '_' {
+			seg.WriteByte('_')
+			i++
+		} else {
+			segments = append(segments, seg.String())
+			seg.Reset()
+		}
+	}
+	segments = append(segments, seg.String())
+
+	var buf strings.Builder
+	for i, s := range segments {
+		if i > 0 && s != "" && strings.Trim(s, "0123456789") == "" {
+			buf.WriteString("[" + s + "]")
+			continue
+		}
+		if i > 0 {
+			buf.WriteRune('.')
+		}
+		buf.WriteString(strings.ToLower(s))
+	}
+	return buf.String()
+}
@@ -4,6 +4,9 @@ package props
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -77,3 +80,191 @@ func TestEnvironmentNames(t *testing.T) {
 		t.Errorf("name not found; found1: %t, found2: %t", found1, found2)
 	}
 }
+
+func TestEnvironmentPrefix(t *testing.T) {
+	os.Setenv("PROPSTEST_DB_HOST", "localhost")
+	os.Setenv("PROPSTEST_MAX__CONNS", "10")
+	os.Setenv("PROPSTEST_MIN_CONNS", "1")
+	os.Setenv("PROPSTEST_SERVERS_0_HOST", "a.example.com")
+	os.Setenv("PROPSTEST_SERVERS_1", "b.example.com")
+	os.Setenv("PROPSTEST_lower", "x")
+	os.Setenv("PROPSTEST_", "y")
+
+	e := &Environment{Prefix: "PROPSTEST_", Normalize: true}
+
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"db.host", "localhost", true},
+		{"max_conns", "10", true},
+		{"max.conns", "", false},
+		{"min_conns", "1", true},
+		{"min.conns", "1", true},
+		{"servers[0].host", "a.example.com", true},
+		{"servers[1]", "b.example.com", true},
+		{"servers.1", "b.example.com", true},
+		{"PROPSTEST_DB_HOST", "", false},
+	}
+	for _, test := range tests {
+		val, ok := e.Get(test.key)
+		if val != test.want || ok != test.found {
+			t.Errorf("%s want: %t, '%s'; got: %t, '%s'", test.key, test.found, test.want, ok, val)
+		}
+	}
+
+	got := e.Names()
+	sort.Strings(got)
+	want := []string{"db.host", "max_conns", "min.conns", "servers[0].host", "servers[1]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	e.Normalize = false
+	val, ok := e.Get("DB_HOST")
+	if !ok || val != "localhost" {
+		t.Errorf("want: true, 'localhost'; got: %t, '%s'", ok, val)
+	}
+	got = e.Names()
+	sort.Strings(got)
+	want = []string{"DB_HOST", "MAX__CONNS", "MIN_CONNS", "SERVERS_0_HOST", "SERVERS_1", "lower"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+var envKeys = []struct {
+	key  string
+	name string
+}{
+	{"db.host", "DB_HOST"},
+	{"max_conns", "MAX__CONNS"},
+	{"servers[0].host", "SERVERS_0_HOST"},
+	{"servers[10]", "SERVERS_10"},
+	{"a_.b", "A___B"},
+	{"0.a", "0_A"},
+	{"a.0b", "A_0B"},
+}
+
+func TestNormalizeKey(t *testing.T) {
+	for _, test := range envKeys {
+		if got := normalizeKey(test.key); got != test.name {
+			t.Errorf("normalize %s want: %s; got: %s", test.key, test.name, got)
+		}
+		if got := denormalizeKey(test.name); got != test.key {
+			t.Errorf("denormalize %s want: %s; got: %s", test.name, test.key, got)
+		}
+	}
+}
+
+func TestFromEnviron(t *testing.T) {
+	t.Parallel()
+
+	e := FromEnviron([]string{"PROPS_TEST_VAL1=abc", "PROPS_TEST_VAL2=", "PROPS_TEST_VAL3=a=b", "INVALID"})
+
+	val, ok := e.Get("PROPS_TEST_VAL1")
+	if !ok || val != "abc" {
+		t.Errorf("want: true, 'abc'; got: %t, '%s'", ok, val)
+	}
+	val, ok = e.Get("PROPS_TEST_VAL2")
+	if !ok || val != "" {
+		t.Errorf("want: true, ''; got: %t, '%s'", ok, val)
+	}
+	val, ok = e.Get("PROPS_TEST_VAL3")
+	if !ok || val != "a=b" {
+		t.Errorf("want: true, 'a=b'; got: %t, '%s'", ok, val)
+	}
+	val, ok = e.Get("PATH")
+	if ok || val != "" {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+
+	got := e.Names()
+	sort.Strings(got)
+	want := []string{"PROPS_TEST_VAL1", "PROPS_TEST_VAL2", "PROPS_TEST_VAL3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	// without a prefix, names are not converted to property keys
+	e.Normalize = true
+	e.Vars["lower_case"] = "x"
+	got = e.Names()
+	sort.Strings(got)
+	want = []string{"PROPS_TEST_VAL1", "PROPS_TEST_VAL2", "PROPS_TEST_VAL3", "lower_case"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	e.Prefix = "PROPS_"
+	val, ok = e.Get("test.val1")
+	if !ok || val != "abc" {
+		t.Errorf("want: true, 'abc'; got: %t, '%s'", ok, val)
+	}
+}
+
+func TestEnvironmentVars(t *testing.T) {
+	t.Parallel()
+
+	e := &Environment{Vars: map[string]string{"APP_DB_HOST": "localhost"}, Prefix: "APP_", Normalize: true}
+	if val := e.GetDefault("db.host", "none"); val != "localhost" {
+		t.Errorf("want: 'localhost'; got: '%s'", val)
+	}
+	if val := e.GetDefault("db.port", "5432"); val != "5432" {
+		t.Errorf("want: '5432'; got: '%s'", val)
+	}
+	got := e.Names()
+	want := []string{"db.host"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+func TestEnvironmentFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := FromEnviron([]string{
+		"APP_DB_PASSWORD_FILE=" + path,
+		"APP_API_KEY=direct",
+		"APP_API_KEY_FILE=" + path,
+		"APP_BAD_FILE=" + filepath.Join(dir, "none"),
+	})
+	e.Prefix = "APP_"
+	e.Normalize = true
+
+	if val, ok := e.Get("db.password"); ok {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+
+	e.Files = true
+	tests := []struct {
+		key   string
+		want  string
+		found bool
+	}{
+		{"db.password", "s3cret", true},
+		{"api.key", "direct", true},
+		{"bad", "", false},
+		{"none", "", false},
+	}
+	for _, test := range tests {
+		val, ok := e.Get(test.key)
+		if val != test.want || ok != test.found {
+			t.Errorf("%s want: %t, '%s'; got: %t, '%s'", test.key, test.found, test.want, ok, val)
+		}
+	}
+
+	got := e.Names()
+	sort.Strings(got)
+	want := []string{"api.key", "api.key.file", "bad", "bad.file", "db.password", "db.password.file"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
@@ -5,6 +5,7 @@ package props
 import (
 	"bytes"
 	"strings"
+	"sync"
 )
 
 // Expander represents a property set that interprets special character
@@ -27,17 +28,148 @@ import (
 //
 // Nested and recursive property expansions are permitted. If a property value
 // does not exist, the property reference will be left unchanged.
+//
+// Additional reference syntaxes can be provided with Delimiters, each with an
+// optional Source of its own. For example, to also expand "%(name)s" and
+// "{{name}}" references:
+//
+//	e.Delimiters = []Delimiter{
+//		{Prefix: "%(", Suffix: ")s"},
+//		{Prefix: "{{", Suffix: "}}", Source: &Environment{}},
+//	}
+//
+// If ExpandKeys is set, property references in keys are expanded as well. For
+// example, with the properties:
+//
+//	region = eu
+//	db.eu.host = eu.example.com
+//	cache.${region}.host = cache-eu.example.com
+//
+// Get("db.${region}.host") would return "eu.example.com" and Names would
+// include "cache.eu.host" which could be retrieved with Get("cache.eu.host").
+//
+// If Expressions is set, references that do not match a property are evaluated
+// as expressions (see Evaluate). For example, with the properties:
+//
+//	env = prod
+//	cache.size = 1024
+//	cache.max = ${cache.size * 2}
+//	log.level = ${env == 'prod' ? 'warn' : 'debug'}
+//
+// cache.max would be "2048" and log.level would be "warn". Expressions that
+// cannot be evaluated are left unchanged.
+//
+// If Cache is set, the results of Get are retained and reused until the
+// version of the Source or of any Delimiter Source changes (see Versioned) or
+// Invalidate is called. Sources that do not implement Versioned, such as a
+// ResolverFunc, will require Invalidate to be called after any changes.
 type Expander struct {
 	// Prefix indicates the start of a property expansion.
 	Prefix string
 	// Suffix indicates the end of a property expansion.
 	Suffix string
+	// Delimiters provides additional prefix and suffix pairs that indicate a
+	// property expansion
+	Delimiters []Delimiter
 	// Limit the nesting depth; <= 0 allows for unlimited nesting
 	Limit int
 	// Source provides the properties to use for expansion
 	Source PropertyGetter
+	// ExpandKeys indicates that property references in keys should also be
+	// expanded
+	ExpandKeys bool
+	// Expressions indicates that references should be evaluated as expressions
+	// if they do not match a property
+	Expressions bool
+	// Cache indicates that expanded values should be retained for reuse
+	Cache bool
+
+	// mu guards the cached values
+	mu sync.RWMutex
+	// cache holds the expanded values by key
+	cache map[string]cachedValue
+	// version is the source version that the cached values are valid for
+	version uint64
+	// gen is incremented by Invalidate so that values computed before it was
+	// called are not cached
+	gen uint64
+}
+
+// Delimiter represents an alternative syntax for property references in an
+// Expander.
+type Delimiter struct {
+	// Prefix indicates the start of a property expansion.
+	Prefix string
+	// Suffix indicates the end of a property expansion.
+	Suffix string
+	// Source provides the properties to use for expansion; if nil, the
+	// Expander's Source is used.
+	Source PropertyGetter
+}
+
+// ResolverFunc adapts a function to a PropertyGetter for use as a Delimiter
+// Source. The Names of a ResolverFunc are always empty.
+type ResolverFunc func(key string) (string, bool)
+
+// Get retrieves the value of a property by calling the function.
+func (f ResolverFunc) Get(key string) (string, bool) {
+	return f(key)
+}
+
+// GetDefault retrieves the value of a property by calling the function. If
+// the property does not exist, then the default value will be returned.
+func (f ResolverFunc) GetDefault(key, defVal string) string {
+	if v, ok := f(key); ok {
+		return v
+	}
+	return defVal
+}
+
+// Names returns an empty list since the keys of a ResolverFunc are unknown.
+func (f ResolverFunc) Names() []string {
+	return []string{}
+}
+
+// expansionState holds the state of a single call to Get, GetDefault, or
+// Names.
+type expansionState struct {
+	// seen holds the values being expanded to detect cycles
+	seen map[string]struct{}
+	// index maps expanded keys to source keys; it is shared by every expansion
+	// of the call so that the source keys are only scanned once. It is nil
+	// while the index itself is being built.
+	index *keyIndex
+}
+
+// keyIndex holds the source keys that contain references by their expanded
+// form (see Expander.keyIndex).
+type keyIndex struct {
+	keys map[string]string
 }
 
+// newExpansionState creates the state for a new call.
+func newExpansionState() *expansionState {
+	return &expansionState{seen: make(map[string]struct{}), index: &keyIndex{}}
+}
+
+// fresh returns a state for an independent expansion in the same call. Values
+// seen are not shared but the key index is.
+func (st *expansionState) fresh() *expansionState {
+	return &expansionState{seen: make(map[string]struct{}), index: st.index}
+}
+
+// cachedValue represents the result of a previous call to Get.
+type cachedValue struct {
+	val string
+	ok  bool
+}
+
+// Ensure that Expander implements PropertyGetter and Versioned
+var (
+	_ PropertyGetter = &Expander{}
+	_ Versioned      = &Expander{}
+)
+
 // NewExpander creates an empty property set with the default expansion
 // Prefix "${" and Suffix "}".
 func NewExpander(source PropertyGetter) *Expander {
@@ -53,26 +185,146 @@ func NewExpander(source PropertyGetter) *Expander {
 // If the property does not exist, an empty string will be returned. The bool
 // return value indicates whether the property was found.
 func (e *Expander) Get(key string) (string, bool) {
-	v, ok := e.Source.Get(key)
-	return e.expand(v, make(map[string]struct{})), ok
+	if !e.Cache {
+		st := newExpansionState()
+		v, ok := e.lookup(key, st)
+		return e.expand(v, st.fresh()), ok
+	}
+
+	ver := e.Version()
+	e.mu.RLock()
+	gen := e.gen
+	if e.cache != nil && e.version == ver {
+		if c, ok := e.cache[key]; ok {
+			e.mu.RUnlock()
+			return c.val, c.ok
+		}
+	}
+	e.mu.RUnlock()
+
+	st := newExpansionState()
+	v, ok := e.lookup(key, st)
+	v = e.expand(v, st.fresh())
+
+	e.mu.Lock()
+	if e.gen == gen {
+		if e.cache == nil || e.version != ver {
+			e.cache = make(map[string]cachedValue)
+			e.version = ver
+		}
+		e.cache[key] = cachedValue{val: v, ok: ok}
+	}
+	e.mu.Unlock()
+	return v, ok
 }
 
 // GetDefault retrieves the value of a property with all property references
 // expanded. If the property does not exist, the default value will be returned
 // with all its property references expanded.
 func (e *Expander) GetDefault(key, defVal string) string {
-	v := e.Source.GetDefault(key, defVal)
-	return e.expand(v, make(map[string]struct{}))
+	if e.Cache {
+		if v, ok := e.Get(key); ok {
+			return v
+		}
+		return e.expand(defVal, newExpansionState())
+	}
+
+	st := newExpansionState()
+	v, ok := e.lookup(key, st)
+	if !ok {
+		v = defVal
+	}
+	return e.expand(v, st.fresh())
 }
 
-// Names returns the keys for all properties in the set.
+// Names returns the keys for all properties in the set. If ExpandKeys is set,
+// the keys will have their property references expanded.
 func (e *Expander) Names() []string {
-	return e.Source.Names()
+	names := e.Source.Names()
+	if !e.ExpandKeys {
+		return names
+	}
+
+	vals := make(map[string]struct{}, len(names))
+	result := make([]string, 0, len(names))
+	st := newExpansionState()
+	for _, name := range names {
+		name = e.expand(name, st.fresh())
+		if _, ok := vals[name]; !ok {
+			vals[name] = struct{}{}
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Version returns the sum of the versions of the Source and any Delimiter
+// Sources that implement Versioned.
+func (e *Expander) Version() uint64 {
+	ver := sourceVersions(e.Source)
+	for _, d := range e.Delimiters {
+		ver += sourceVersions(d.Source)
+	}
+	return ver
+}
+
+// Invalidate discards all cached values.
+func (e *Expander) Invalidate() {
+	e.mu.Lock()
+	e.cache = nil
+	e.gen++
+	e.mu.Unlock()
+}
+
+// lookup retrieves the unexpanded value of a property from the source. If
+// ExpandKeys is set, references in the key are expanded first and keys in the
+// source that expand to the same value are also considered.
+func (e *Expander) lookup(key string, st *expansionState) (string, bool) {
+	if !e.ExpandKeys {
+		return e.Source.Get(key)
+	}
+
+	exp := e.expand(key, st)
+	if v, ok := e.Source.Get(exp); ok {
+		return v, true
+	}
+
+	if st.index != nil {
+		if name, ok := e.keyIndex(st.index)[exp]; ok {
+			return e.Source.Get(name)
+		}
+	}
+
+	if exp != key {
+		return e.Source.Get(key)
+	}
+	return "", false
+}
+
+// keyIndex returns the source keys that contain references by their expanded
+// form, building it on first use. The keys are expanded without the index, so
+// references in keys only resolve to keys that exist in the source as written.
+func (e *Expander) keyIndex(idx *keyIndex) map[string]string {
+	if idx.keys != nil {
+		return idx.keys
+	}
+	idx.keys = make(map[string]string)
+	for _, name := range e.Source.Names() {
+		if !e.hasRef(name) {
+			continue
+		}
+		exp := e.expand(name, &expansionState{seen: make(map[string]struct{})})
+		if _, ok := idx.keys[exp]; !ok {
+			idx.keys[exp] = name
+		}
+	}
+	return idx.keys
 }
 
 // expand any embedded property references in a string
-func (e *Expander) expand(v string, seen map[string]struct{}) string {
-	if v == "" || !strings.Contains(v, e.Prefix) || !strings.Contains(v, e.Suffix) {
+func (e *Expander) expand(v string, st *expansionState) string {
+	seen := st.seen
+	if v == "" || !e.hasRef(v) {
 		return v
 	}
 
@@ -87,51 +339,155 @@ func (e *Expander) expand(v string, seen map[string]struct{}) string {
 
 	seen[v] = struct{}{}
 
+	delims := e.delimiters()
 	var out bytes.Buffer
-	start := 0
-	nest := 0
+	last := 0
 	for i := 0; i < len(v); i++ {
-		if !strings.HasPrefix(v[i:], e.Prefix) {
+		d := delimiterAt(delims, v, i)
+		if d == nil {
+			continue
+		}
+		start := i + len(d.Prefix)
+		end := refEnd(delims, v, start, d)
+		if end < 0 {
+			// no matching suffix
 			continue
 		}
 
-		out.WriteString(v[start:i])
-		start = i + len(e.Prefix)
-
-		for j := start; j < len(v); j++ {
-			if strings.HasPrefix(v[j:], e.Suffix) {
-				if nest == 0 {
-					exp := e.expand(v[start:j], seen)
-					val, _ := e.Source.Get(exp)
-					if len(val) == 0 {
-						out.WriteString(e.Prefix)
-						out.WriteString(exp)
-						out.WriteString(e.Suffix)
-					} else {
-						out.WriteString(val)
-					}
-					start = j + len(e.Suffix)
-					i = start - 1
-					break
-				} else {
-					nest--
-				}
-			} else if strings.HasPrefix(v[j:], e.Prefix) {
-				nest++
-			}
+		out.WriteString(v[last:i])
+		exp := e.expand(v[start:end], st)
+		val := e.resolve(d, exp, st)
+		if len(val) == 0 {
+			out.WriteString(d.Prefix)
+			out.WriteString(exp)
+			out.WriteString(d.Suffix)
+		} else {
+			out.WriteString(val)
 		}
+		last = end + len(d.Suffix)
+		i = last - 1
 	}
 
-	if start < len(v) {
-		out.WriteString(v[start:])
+	if last < len(v) {
+		out.WriteString(v[last:])
 	}
 
 	result := out.String()
 
 	// expand properties recursively
 	if v == result {
-		return out.String()
+		return result
 	} else {
-		return e.expand(out.String(), seen)
+		return e.expand(result, st)
+	}
+}
+
+// resolve retrieves the value for an expanded property reference using the
+// delimiter's source if it has one.
+func (e *Expander) resolve(d *Delimiter, key string, st *expansionState) string {
+	if d.Source != nil {
+		val, _ := d.Source.Get(key)
+		return val
+	}
+
+	val, _ := e.lookup(key, st)
+	if len(val) == 0 && e.Expressions {
+		val, _ = Evaluate(key, &expansion{e: e, st: st})
+	}
+	return val
+}
+
+// delimiters returns the Prefix and Suffix followed by any additional
+// Delimiters. Delimiters without both a prefix and suffix are skipped.
+func (e *Expander) delimiters() []Delimiter {
+	delims := make([]Delimiter, 0, len(e.Delimiters)+1)
+	if e.Prefix != "" && e.Suffix != "" {
+		delims = append(delims, Delimiter{Prefix: e.Prefix, Suffix: e.Suffix})
+	}
+	for _, d := range e.Delimiters {
+		if d.Prefix != "" && d.Suffix != "" {
+			delims = append(delims, d)
+		}
 	}
+	return delims
+}
+
+// hasRef determines whether a string may contain a property reference.
+func (e *Expander) hasRef(v string) bool {
+	if e.Prefix != "" && e.Suffix != "" && strings.Contains(v, e.Prefix) && strings.Contains(v, e.Suffix) {
+		return true
+	}
+	for _, d := range e.Delimiters {
+		if d.Prefix != "" && d.Suffix != "" && strings.Contains(v, d.Prefix) && strings.Contains(v, d.Suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// delimiterAt returns the delimiter with the longest prefix that starts at
+// position i of v or nil if there is none.
+func delimiterAt(delims []Delimiter, v string, i int) *Delimiter {
+	var found *Delimiter
+	for k := range delims {
+		if strings.HasPrefix(v[i:], delims[k].Prefix) && (found == nil || len(delims[k].Prefix) > len(found.Prefix)) {
+			found = &delims[k]
+		}
+	}
+	return found
+}
+
+// refEnd returns the position of the suffix that ends a reference started by
+// d, skipping over any nested references, or -1 if there is none.
+func refEnd(delims []Delimiter, v string, start int, d *Delimiter) int {
+	for j := start; j < len(v); j++ {
+		if strings.HasPrefix(v[j:], d.Suffix) {
+			return j
+		}
+		if nested := delimiterAt(delims, v, j); nested != nil {
+			end := refEnd(delims, v, j+len(nested.Prefix), nested)
+			if end < 0 {
+				return -1
+			}
+			j = end + len(nested.Suffix) - 1
+		}
+	}
+	return -1
+}
+
+// expansion provides expanded property values to expressions evaluated during
+// an expansion. A reference to a value that is still being expanded is a cycle
+// and is treated as a missing property.
+type expansion struct {
+	e  *Expander
+	st *expansionState
+}
+
+func (x *expansion) Get(key string) (string, bool) {
+	v, ok := x.e.lookup(key, x.st)
+	if !ok {
+		return "", false
+	}
+	if _, ok := x.st.seen[v]; ok {
+		return "", false
+	}
+
+	// the value is expanded with its own copy of the values seen so that
+	// other references in the same expression are not treated as cycles
+	seen := make(map[string]struct{}, len(x.st.seen))
+	for k := range x.st.seen {
+		seen[k] = struct{}{}
+	}
+	return x.e.expand(v, &expansionState{seen: seen, index: x.st.index}), true
+}
+
+func (x *expansion) GetDefault(key, defVal string) string {
+	if v, ok := x.Get(key); ok {
+		return v
+	}
+	return defVal
+}
+
+func (x *expansion) Names() []string {
+	return x.e.Names()
 }
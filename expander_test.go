@@ -5,7 +5,9 @@ package props
 import (
 	"reflect"
 	"sort"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestNewExpander(t *testing.T) {
@@ -218,3 +220,346 @@ func TestExpanderNames(t *testing.T) {
 		t.Errorf("want: %v; got: %v", want, got)
 	}
 }
+
+var keyExpand = []expTest{
+	{"db.${region}.host", "", "eu.example.com"},
+	{"cache.${region}.host", "", "cache-eu.example.com"},
+	{"cache.eu.host", "", "cache-eu.example.com"},
+	{"${svc}.${region}.host", "", "eu.example.com"},
+	{"db.${zzz}.host", "", "literal"},
+	{"val", "", "eu.example.com"},
+}
+
+func TestExpandKeys(t *testing.T) {
+	p := NewProperties()
+	p.Set("region", "eu")
+	p.Set("svc", "db")
+	p.Set("db.eu.host", "eu.example.com")
+	p.Set("cache.${region}.host", "cache-${region}.example.com")
+	p.Set("db.${zzz}.host", "literal")
+	p.Set("val", "${db.${region}.host}")
+
+	e := NewExpander(p)
+	e.ExpandKeys = true
+
+	for _, test := range keyExpand {
+		got, ok := e.Get(test.key)
+		if got != test.want || !ok {
+			t.Errorf("%s want: %q; got: %q, %t", test.key, test.want, got, ok)
+		}
+	}
+
+	if v := e.GetDefault("cache.${region}.port", "${region}"); v != "eu" {
+		t.Errorf("want: eu; got: %s", v)
+	}
+
+	got := e.Names()
+	sort.Strings(got)
+	want := []string{"cache.eu.host", "db.${zzz}.host", "db.eu.host", "region", "svc", "val"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+
+	e.ExpandKeys = false
+	if v, ok := e.Get("cache.eu.host"); ok {
+		t.Errorf("want: '', false; got: %q, %t", v, ok)
+	}
+}
+
+func TestExpandKeysCycle(t *testing.T) {
+	p := NewProperties()
+	p.Set("a${b}", "${a${b}}")
+	p.Set("b", "${a${b}}")
+
+	e := NewExpander(p)
+	e.ExpandKeys = true
+
+	// only checking for termination
+	e.Get("a${b}")
+	e.Get("b")
+	e.Names()
+}
+
+func TestExpanderCache(t *testing.T) {
+	p := NewProperties()
+	p.Set("one", "1")
+	p.Set("key", "foo${one}bar")
+
+	e := NewExpander(p)
+	e.Cache = true
+
+	if v, ok := e.Get("key"); v != "foo1bar" || !ok {
+		t.Errorf("want: 'foo1bar', true; got: %q, %t", v, ok)
+	}
+	if v, ok := e.Get("none"); v != "" || ok {
+		t.Errorf("want: '', false; got: %q, %t", v, ok)
+	}
+	if v := e.GetDefault("none", "${one}"); v != "1" {
+		t.Errorf("want: '1'; got: %q", v)
+	}
+
+	p.Set("one", "2")
+	if v, _ := e.Get("key"); v != "foo2bar" {
+		t.Errorf("want: 'foo2bar'; got: %q", v)
+	}
+	p.Set("none", "found")
+	if v, ok := e.Get("none"); v != "found" || !ok {
+		t.Errorf("want: 'found', true; got: %q, %t", v, ok)
+	}
+
+	// unversioned sources require explicit invalidation
+	c := &unversioned{p: NewProperties()}
+	c.p.Set("key", "a")
+	e = NewExpander(c)
+	e.Cache = true
+	e.Get("key")
+	c.p.Set("key", "b")
+	if v, _ := e.Get("key"); v != "a" {
+		t.Errorf("want: 'a'; got: %q", v)
+	}
+	e.Invalidate()
+	if v, _ := e.Get("key"); v != "b" {
+		t.Errorf("want: 'b'; got: %q", v)
+	}
+}
+
+func TestExpanderCacheCombined(t *testing.T) {
+	p1 := NewProperties()
+	p2 := NewProperties()
+	p2.Set("key", "${val}")
+	p2.Set("val", "a")
+	e := NewExpander(&Combined{Sources: []PropertyGetter{p1, p2}})
+	e.Cache = true
+
+	if v, _ := e.Get("key"); v != "a" {
+		t.Errorf("want: 'a'; got: %q", v)
+	}
+	p1.Set("val", "b")
+	if v, _ := e.Get("key"); v != "b" {
+		t.Errorf("want: 'b'; got: %q", v)
+	}
+}
+
+func TestExpanderCacheAllocs(t *testing.T) {
+	p := NewProperties()
+	p.Set("one", "1")
+	p.Set("key", "foo${one}bar")
+	e := NewExpander(&Combined{Sources: []PropertyGetter{p}})
+	e.Cache = true
+	e.Get("key")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		e.Get("key")
+	})
+	if allocs != 0 {
+		t.Errorf("want: 0 allocs; got: %f", allocs)
+	}
+}
+
+func TestExpanderCacheInvalidateRace(t *testing.T) {
+	val := "old"
+	var e *Expander
+	src := ResolverFunc(func(key string) (string, bool) {
+		v := val
+		// the source changes and is invalidated while Get is computing
+		val = "new"
+		e.Invalidate()
+		return v, true
+	})
+	e = NewExpander(src)
+	e.Cache = true
+
+	if v, _ := e.Get("key"); v != "old" {
+		t.Errorf("want: 'old'; got: %q", v)
+	}
+	if v, _ := e.Get("key"); v != "new" {
+		t.Errorf("want: 'new' after invalidate; got: %q", v)
+	}
+}
+
+type unversioned struct {
+	p *Properties
+}
+
+func (u *unversioned) Get(key string) (string, bool)        { return u.p.Get(key) }
+func (u *unversioned) GetDefault(key, defVal string) string { return u.p.GetDefault(key, defVal) }
+func (u *unversioned) Names() []string                      { return u.p.Names() }
+
+var exprExpand = []expTest{
+	{"key1", "${cache.size * 2}", "2048"},
+	{"key2", "${env == 'prod' ? 'warn' : 'debug'}", "warn"},
+	{"key3", "${'cache-' + env}", "cache-prod"},
+	{"key4", "${derived * 2}", "4096"},
+	{"key5", "${missing * 2}", "${missing * 2}"},
+	{"key6", "${loop * 2}", "${loop * 2}"},
+	{"key7", "${${name} * 2}", "2048"},
+	{"key8", "size=${cache.size}", "size=1024"},
+}
+
+func TestExpressions(t *testing.T) {
+	p := NewProperties()
+	p.Set("cache.size", "1024")
+	p.Set("env", "prod")
+	p.Set("derived", "${cache.size * 2}")
+	p.Set("loop", "${loop * 2}")
+	p.Set("name", "cache.size")
+
+	for _, test := range exprExpand {
+		p.Set(test.key, test.val)
+	}
+
+	e := NewExpander(p)
+	e.Expressions = true
+
+	for _, test := range exprExpand {
+		got, ok := e.Get(test.key)
+		if got != test.want || !ok {
+			t.Errorf("want: %q; got: %q, %t", test.want, got, ok)
+		}
+	}
+
+	e.Expressions = false
+	if got, _ := e.Get("key1"); got != "${cache.size * 2}" {
+		t.Errorf("want: %q; got: %q", "${cache.size * 2}", got)
+	}
+}
+
+func TestExpressionsCycle(t *testing.T) {
+	p := NewProperties()
+	p.Set("self", "${self + 1}")
+	p.Set("a", "${b + 1}")
+	p.Set("b", "${a + 1}")
+	p.Set("one", "${two}")
+	p.Set("two", "2")
+	p.Set("twice", "${one + one}")
+
+	e := NewExpander(p)
+	e.Expressions = true
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if got, _ := e.Get("self"); got != "${self + 1}" {
+			t.Errorf("want: %q; got: %q", "${self + 1}", got)
+		}
+		e.Get("a")
+		e.Get("b")
+
+		// references that are not cycles still resolve
+		if got, _ := e.Get("twice"); got != "4" {
+			t.Errorf("want: '4'; got: %q", got)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expression cycle did not finish")
+	}
+}
+
+var delimExpand = []expTest{
+	{"key1", "${one}-%(two)s-{{three}}", "1-2-3"},
+	{"key2", "%(${name})s", "2"},
+	{"key3", "${%(name)s}", "2"},
+	{"key4", "{{x}}", "resolved-x"},
+	{"key5", "${a{{b}}}", "AB"},
+	{"key6", "%(zzz)s ${zzz} {{zzz}}", "%(zzz)s ${zzz} {{zzz}}"},
+	{"key7", "%(two", "%(two"},
+	{"key8", "a${b ${one}", "a${b 1"},
+}
+
+func TestDelimiters(t *testing.T) {
+	p := NewProperties()
+	p.Set("one", "1")
+	p.Set("two", "2")
+	p.Set("name", "two")
+	p.Set("aB", "AB")
+
+	for _, test := range delimExpand {
+		p.Set(test.key, test.val)
+	}
+
+	e := NewExpander(p)
+	e.Delimiters = []Delimiter{
+		{Prefix: "%(", Suffix: ")s"},
+		{Prefix: "{{", Suffix: "}}", Source: ResolverFunc(func(key string) (string, bool) {
+			switch key {
+			case "three":
+				return "3", true
+			case "b":
+				return "B", true
+			case "x":
+				return "resolved-x", true
+			}
+			return "", false
+		})},
+		{Prefix: "", Suffix: "]"},
+	}
+
+	for _, test := range delimExpand {
+		got, ok := e.Get(test.key)
+		if got != test.want || !ok {
+			t.Errorf("%s want: %q; got: %q, %t", test.key, test.want, got, ok)
+		}
+	}
+}
+
+func TestDelimitersCache(t *testing.T) {
+	p := NewProperties()
+	p.Set("a", "x {{name}}")
+	other := NewProperties()
+	other.Set("name", "bob")
+
+	e := NewExpander(p)
+	e.Cache = true
+	e.Delimiters = []Delimiter{{Prefix: "{{", Suffix: "}}", Source: other}}
+
+	if v, _ := e.Get("a"); v != "x bob" {
+		t.Errorf("want: 'x bob'; got: %q", v)
+	}
+	other.Set("name", "alice")
+	if v, _ := e.Get("a"); v != "x alice" {
+		t.Errorf("want: 'x alice'; got: %q", v)
+	}
+}
+
+func TestResolverFunc(t *testing.T) {
+	f := ResolverFunc(func(key string) (string, bool) {
+		return "val", key == "key"
+	})
+
+	if v := f.GetDefault("key", "def"); v != "val" {
+		t.Errorf("want: 'val'; got: %q", v)
+	}
+	if v := f.GetDefault("none", "def"); v != "def" {
+		t.Errorf("want: 'def'; got: %q", v)
+	}
+	if n := f.Names(); len(n) != 0 {
+		t.Errorf("want: []; got: %v", n)
+	}
+}
+
+func TestExpandKeysMissing(t *testing.T) {
+	p := NewProperties()
+	for i := 0; i < 20; i++ {
+		p.Set("k"+strconv.Itoa(i)+".${missing"+strconv.Itoa(i)+"}", "v"+strconv.Itoa(i))
+	}
+	p.Set("ref", "${k3.${missing3}}")
+
+	e := NewExpander(p)
+	e.ExpandKeys = true
+
+	// unresolved references in keys must not be expanded once per key
+	if v, ok := e.Get("nothere"); v != "" || ok {
+		t.Errorf("want: '', false; got: %q, %t", v, ok)
+	}
+	if v, ok := e.Get("k7.${missing7}"); v != "v7" || !ok {
+		t.Errorf("want: 'v7', true; got: %q, %t", v, ok)
+	}
+	if v, ok := e.Get("ref"); v != "v3" || !ok {
+		t.Errorf("want: 'v3', true; got: %q, %t", v, ok)
+	}
+	if n := len(e.Names()); n != 21 {
+		t.Errorf("want: 21 names; got: %d", n)
+	}
+}
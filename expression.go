@@ -0,0 +1,542 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxExprDepth limits the nesting depth of an expression.
+const maxExprDepth = 64
+
+/*
+Evaluate computes the result of a simple expression that may refer to other
+property values. The evaluator only supports the operations listed below and
+has no access to anything other than the provided property values.
+
+# Values
+
+Literal values may be numbers (10, 2.5), strings in single or double quotes
+('prod', "a b") where the quote is written twice to include it in the string,
+or booleans (true, false). Names that start with a letter or '_' and contain
+letters, digits, '_', '.', '[', or ']' are property references and are replaced
+with the property value; it is an error to refer to a missing property.
+
+Property values are treated as numbers or booleans when the operation requires
+it and the value can be converted.
+
+# Operators
+
+In order of precedence from lowest to highest:
+
+	c ? a : b    - a if c is true; otherwise b (only one is evaluated)
+	||           - logical or
+	&&           - logical and
+	== !=        - equality; numeric if both sides are numbers
+	< <= > >=    - comparison; numeric if both sides are numbers
+	+ -          - addition/subtraction; + concatenates non-numbers
+	* / %        - multiplication, division, and remainder
+	! -          - logical not and negation
+	( )          - grouping
+
+# Examples
+
+With the properties cache.size=1024 and env=prod:
+
+	cache.size * 2                       -> 2048
+	env == 'prod' ? 'warn' : 'debug'     -> warn
+	'cache-' + env                       -> cache-prod
+	cache.size > 512 && env != 'dev'     -> true
+*/
+func Evaluate(expr string, p PropertyGetter) (string, error) {
+	toks, err := lexExpr(expr)
+	if err != nil {
+		return "", err
+	}
+	ps := &parser{toks: toks, props: p}
+	f, err := ps.ternary(0)
+	if err != nil {
+		return "", err
+	}
+	if ps.pos < len(ps.toks) {
+		return "", fmt.Errorf("unexpected %s in expression", ps.toks[ps.pos].text)
+	}
+	v, err := f()
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// exprKind identifies the type of an expression value.
+type exprKind int
+
+const (
+	// kindProp is an untyped property value that may be used as any type
+	kindProp exprKind = iota
+	kindString
+	kindNumber
+	kindBool
+)
+
+// exprValue holds the result of evaluating part of an expression.
+type exprValue struct {
+	kind exprKind
+	str  string
+	num  float64
+	b    bool
+}
+
+// String formats the value for use as a property value.
+func (v exprValue) String() string {
+	switch v.kind {
+	case kindNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	default:
+		return v.str
+	}
+}
+
+// number returns the numeric value and whether the value is a number.
+func (v exprValue) number() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindProp:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// boolean returns the bool value or an error if the value is not a bool.
+func (v exprValue) boolean() (bool, error) {
+	switch v.kind {
+	case kindBool:
+		return v.b, nil
+	case kindProp:
+		b, err := strconv.ParseBool(strings.TrimSpace(v.str))
+		if err != nil {
+			return false, fmt.Errorf("invalid bool value %s", v.str)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("invalid bool value %s", v.String())
+	}
+}
+
+// exprToken represents a single lexical element of an expression.
+type exprToken struct {
+	// op is set for operators and parentheses
+	op bool
+	// ref is set for property references
+	ref  bool
+	text string
+	val  exprValue
+}
+
+// lexExpr splits an expression into tokens.
+func lexExpr(expr string) ([]exprToken, error) {
+	toks := make([]exprToken, 0, 8)
+	for i := 0; i < len(expr); {
+		ch := rune(expr[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch >= '0' && ch <= '9':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(expr[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s in expression", expr[i:j])
+			}
+			toks = append(toks, exprToken{text: expr[i:j], val: exprValue{kind: kindNumber, num: n}})
+			i = j
+		case ch == '\'' || ch == '"':
+			var buf strings.Builder
+			j := i + 1
+			for {
+				if j >= len(expr) {
+					return nil, fmt.Errorf("unterminated string in expression")
+				}
+				if expr[j] == byte(ch) {
+					if j+1 < len(expr) && expr[j+1] == byte(ch) {
+						buf.WriteByte(byte(ch))
+						j += 2
+						continue
+					}
+					break
+				}
+				buf.WriteByte(expr[j])
+				j++
+			}
+			toks = append(toks, exprToken{text: expr[i : j+1], val: exprValue{kind: kindString, str: buf.String()}})
+			i = j + 1
+		case ch == '_' || unicode.IsLetter(ch):
+			j := i
+			for j < len(expr) && isExprIdent(rune(expr[j])) {
+				j++
+			}
+			name := expr[i:j]
+			if name == "true" || name == "false" {
+				toks = append(toks, exprToken{text: name, val: exprValue{kind: kindBool, b: name == "true"}})
+			} else {
+				toks = append(toks, exprToken{ref: true, text: name})
+			}
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")"} {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %c in expression", ch)
+			}
+			toks = append(toks, exprToken{op: true, text: op})
+			i += len(op)
+		}
+	}
+	return toks, nil
+}
+
+// isExprIdent returns true for characters allowed in a property name within
+// an expression.
+func isExprIdent(ch rune) bool {
+	return ch == '_' || ch == '.' || ch == '[' || ch == ']' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+// exprFunc computes the value of a parsed expression.
+type exprFunc func() (exprValue, error)
+
+// parser converts expression tokens into an exprFunc with recursive descent;
+// each function handles one level of operator precedence. Operands are only
+// evaluated when needed so that unused branches may refer to missing
+// properties.
+type parser struct {
+	toks  []exprToken
+	pos   int
+	props PropertyGetter
+}
+
+// accept consumes the next token if it is one of the operators.
+func (ps *parser) accept(ops ...string) (string, bool) {
+	if ps.pos >= len(ps.toks) || !ps.toks[ps.pos].op {
+		return "", false
+	}
+	for _, op := range ops {
+		if ps.toks[ps.pos].text == op {
+			ps.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// ternary handles c ? a : b
+func (ps *parser) ternary(depth int) (exprFunc, error) {
+	if depth > maxExprDepth {
+		return nil, fmt.Errorf("expression too complex")
+	}
+	cond, err := ps.or(depth)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ps.accept("?"); !ok {
+		return cond, nil
+	}
+	a, err := ps.ternary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ps.accept(":"); !ok {
+		return nil, fmt.Errorf("missing : in expression")
+	}
+	b, err := ps.ternary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return func() (exprValue, error) {
+		c, err := evalBool(cond)
+		if err != nil {
+			return exprValue{}, err
+		}
+		if c {
+			return a()
+		}
+		return b()
+	}, nil
+}
+
+// or handles ||
+func (ps *parser) or(depth int) (exprFunc, error) {
+	left, err := ps.and(depth)
+	for err == nil {
+		if _, ok := ps.accept("||"); !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.and(depth)
+		left = logical(left, right, true)
+	}
+	return left, err
+}
+
+// and handles &&
+func (ps *parser) and(depth int) (exprFunc, error) {
+	left, err := ps.equality(depth)
+	for err == nil {
+		if _, ok := ps.accept("&&"); !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.equality(depth)
+		left = logical(left, right, false)
+	}
+	return left, err
+}
+
+// equality handles == and !=
+func (ps *parser) equality(depth int) (exprFunc, error) {
+	left, err := ps.comparison(depth)
+	for err == nil {
+		op, ok := ps.accept("==", "!=")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.comparison(depth)
+		left = binaryOp(left, right, func(l, r exprValue) (exprValue, error) {
+			var eq bool
+			ln, lok := l.number()
+			rn, rok := r.number()
+			if lok && rok {
+				eq = ln == rn
+			} else {
+				eq = l.String() == r.String()
+			}
+			return boolValue(eq == (op == "==")), nil
+		})
+	}
+	return left, err
+}
+
+// comparison handles <, <=, >, and >=
+func (ps *parser) comparison(depth int) (exprFunc, error) {
+	left, err := ps.additive(depth)
+	for err == nil {
+		op, ok := ps.accept("<=", ">=", "<", ">")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.additive(depth)
+		left = binaryOp(left, right, func(l, r exprValue) (exprValue, error) {
+			var cmp int
+			ln, lok := l.number()
+			rn, rok := r.number()
+			if lok && rok {
+				if ln < rn {
+					cmp = -1
+				} else if ln > rn {
+					cmp = 1
+				}
+			} else {
+				cmp = strings.Compare(l.String(), r.String())
+			}
+			switch op {
+			case "<":
+				return boolValue(cmp < 0), nil
+			case "<=":
+				return boolValue(cmp <= 0), nil
+			case ">":
+				return boolValue(cmp > 0), nil
+			default:
+				return boolValue(cmp >= 0), nil
+			}
+		})
+	}
+	return left, err
+}
+
+// additive handles + and -
+func (ps *parser) additive(depth int) (exprFunc, error) {
+	left, err := ps.multiplicative(depth)
+	for err == nil {
+		op, ok := ps.accept("+", "-")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.multiplicative(depth)
+		left = binaryOp(left, right, func(l, r exprValue) (exprValue, error) {
+			ln, lok := l.number()
+			rn, rok := r.number()
+			if op == "+" && (!lok || !rok || l.kind == kindString || r.kind == kindString) {
+				return exprValue{kind: kindString, str: l.String() + r.String()}, nil
+			} else if !lok || !rok {
+				return exprValue{}, fmt.Errorf("invalid number for %s %s %s", l.String(), op, r.String())
+			} else if op == "+" {
+				return exprValue{kind: kindNumber, num: ln + rn}, nil
+			}
+			return exprValue{kind: kindNumber, num: ln - rn}, nil
+		})
+	}
+	return left, err
+}
+
+// multiplicative handles *, /, and %
+func (ps *parser) multiplicative(depth int) (exprFunc, error) {
+	left, err := ps.unary(depth)
+	for err == nil {
+		op, ok := ps.accept("*", "/", "%")
+		if !ok {
+			break
+		}
+		var right exprFunc
+		right, err = ps.unary(depth)
+		left = binaryOp(left, right, func(l, r exprValue) (exprValue, error) {
+			ln, lok := l.number()
+			rn, rok := r.number()
+			if !lok || !rok {
+				return exprValue{}, fmt.Errorf("invalid number for %s %s %s", l.String(), op, r.String())
+			}
+			switch op {
+			case "*":
+				return exprValue{kind: kindNumber, num: ln * rn}, nil
+			case "/":
+				if rn == 0 {
+					return exprValue{}, fmt.Errorf("division by zero")
+				}
+				return exprValue{kind: kindNumber, num: ln / rn}, nil
+			default:
+				if rn == 0 {
+					return exprValue{}, fmt.Errorf("division by zero")
+				}
+				return exprValue{kind: kindNumber, num: math.Mod(ln, rn)}, nil
+			}
+		})
+	}
+	return left, err
+}
+
+// unary handles ! and -
+func (ps *parser) unary(depth int) (exprFunc, error) {
+	op, ok := ps.accept("!", "-")
+	if !ok {
+		return ps.primary(depth)
+	}
+	if depth > maxExprDepth {
+		return nil, fmt.Errorf("expression too complex")
+	}
+	operand, err := ps.unary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if op == "!" {
+		return func() (exprValue, error) {
+			b, err := evalBool(operand)
+			return boolValue(!b), err
+		}, nil
+	}
+	return func() (exprValue, error) {
+		v, err := operand()
+		if err != nil {
+			return v, err
+		}
+		n, ok := v.number()
+		if !ok {
+			return exprValue{}, fmt.Errorf("invalid number for -%s", v.String())
+		}
+		return exprValue{kind: kindNumber, num: -n}, nil
+	}, nil
+}
+
+// primary handles literals, property references, and grouping
+func (ps *parser) primary(depth int) (exprFunc, error) {
+	if _, ok := ps.accept("("); ok {
+		f, err := ps.ternary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ps.accept(")"); !ok {
+			return nil, fmt.Errorf("missing ) in expression")
+		}
+		return f, nil
+	}
+
+	if ps.pos >= len(ps.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := ps.toks[ps.pos]
+	if tok.op {
+		return nil, fmt.Errorf("unexpected %s in expression", tok.text)
+	}
+	ps.pos++
+	if tok.ref {
+		props := ps.props
+		return func() (exprValue, error) {
+			v, ok := props.Get(tok.text)
+			if !ok {
+				return exprValue{}, fmt.Errorf("missing property %s", tok.text)
+			}
+			return exprValue{kind: kindProp, str: v}, nil
+		}, nil
+	}
+	return func() (exprValue, error) {
+		return tok.val, nil
+	}, nil
+}
+
+// binaryOp combines the values of two expressions with an operation.
+func binaryOp(left, right exprFunc, op func(l, r exprValue) (exprValue, error)) exprFunc {
+	return func() (exprValue, error) {
+		l, err := left()
+		if err != nil {
+			return l, err
+		}
+		r, err := right()
+		if err != nil {
+			return r, err
+		}
+		return op(l, r)
+	}
+}
+
+// logical combines the values of two boolean expressions with || (or is true)
+// or && (or is false). The right expression is only evaluated if needed.
+func logical(left, right exprFunc, or bool) exprFunc {
+	return func() (exprValue, error) {
+		l, err := evalBool(left)
+		if err != nil || l == or {
+			return boolValue(l), err
+		}
+		r, err := evalBool(right)
+		return boolValue(r), err
+	}
+}
+
+// evalBool computes the value of an expression as a bool.
+func evalBool(f exprFunc) (bool, error) {
+	v, err := f()
+	if err != nil {
+		return false, err
+	}
+	return v.boolean()
+}
+
+// boolValue converts a bool to an expression value.
+func boolValue(b bool) exprValue {
+	return exprValue{kind: kindBool, b: b}
+}
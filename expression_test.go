@@ -0,0 +1,103 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"testing"
+)
+
+var evalTests = []struct {
+	expr    string
+	want    string
+	wantErr bool
+}{
+	{"cache.size * 2", "2048", false},
+	{"cache.size / 3", "341.3333333333333", false},
+	{"cache.size % 1000", "24", false},
+	{"cache.size + 1", "1025", false},
+	{"cache.size - 1024", "0", false},
+	{"-cache.size", "-1024", false},
+	{"2 + 3 * 4", "14", false},
+	{"(2 + 3) * 4", "20", false},
+	{"1.5 * 2", "3", false},
+	{"env == 'prod' ? 'warn' : 'debug'", "warn", false},
+	{"env != 'prod' ? 'warn' : 'debug'", "debug", false},
+	{"env == \"prod\"", "true", false},
+	{"'cache-' + env", "cache-prod", false},
+	{"env + '-' + cache.size", "prod-1024", false},
+	{"'1' + 2", "12", false},
+	{"'it''s'", "it's", false},
+	{"cache.size > 512 && env != 'dev'", "true", false},
+	{"cache.size < 512 || enabled", "true", false},
+	{"!enabled", "false", false},
+	{"cache.size >= 1024", "true", false},
+	{"cache.size <= 1023", "false", false},
+	{"'b' > 'a'", "true", false},
+	{"cache.size == 1024.0", "true", false},
+	{"cache.size == '1024.0'", "false", false},
+	{"enabled ? 1 : missing", "1", false},
+	{"enabled || missing", "true", false},
+	{"!enabled && missing", "false", false},
+	{"a ? b ? 1 : 2 : 3", "2", false},
+	{"true", "true", false},
+	{"servers[0].host", "a.example.com", false},
+
+	{"missing", "", true},
+	{"env * 2", "", true},
+	{"env - 1", "", true},
+	{"-env", "", true},
+	{"!env", "", true},
+	{"env ? 1 : 2", "", true},
+	{"env && true", "", true},
+	{"1 / 0", "", true},
+	{"1 % 0", "", true},
+	{"1 +", "", true},
+	{"(1 + 2", "", true},
+	{"1 ? 2", "", true},
+	{"1 2", "", true},
+	{"'open", "", true},
+	{"1 $ 2", "", true},
+	{"1.2.3", "", true},
+	{")", "", true},
+}
+
+func TestEvaluate(t *testing.T) {
+	p := NewProperties()
+	p.Set("cache.size", "1024")
+	p.Set("env", "prod")
+	p.Set("enabled", "true")
+	p.Set("a", "true")
+	p.Set("b", "false")
+	p.Set("servers[0].host", "a.example.com")
+
+	for _, test := range evalTests {
+		got, err := Evaluate(test.expr, p)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s want: error; got: %q", test.expr, got)
+			}
+			continue
+		}
+		if got != test.want || err != nil {
+			t.Errorf("%s want: %q, nil; got: %q, %v", test.expr, test.want, got, err)
+		}
+	}
+}
+
+func TestEvaluateDepth(t *testing.T) {
+	expr := ""
+	for i := 0; i < 100; i++ {
+		expr += "("
+	}
+	if _, err := Evaluate(expr+"1", NewProperties()); err == nil {
+		t.Error("want: error; got: nil")
+	}
+
+	expr = ""
+	for i := 0; i < 100; i++ {
+		expr += "-"
+	}
+	if _, err := Evaluate(expr+"1", NewProperties()); err == nil {
+		t.Error("want: error; got: nil")
+	}
+}
@@ -0,0 +1,148 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Flags reads properties from the flags of a flag.FlagSet that were set on
+// the command line. Flags that were not set are not included; use
+// FlagDefaults to provide their default values as a separate source.
+//
+// For example, to have command line flags override a property file which in
+// turn overrides the flag defaults:
+//
+//	fs.Parse(os.Args[1:])
+//	c := &Combined{
+//		Sources: []PropertyGetter{
+//			&Flags{Set: fs},
+//			fileProps,
+//			&FlagDefaults{Set: fs},
+//		},
+//	}
+type Flags struct {
+	// Set provides the flags to read. If nil, flag.CommandLine will be used.
+	Set *flag.FlagSet
+}
+
+// Ensure that Flags implements PropertyGetter
+var _ PropertyGetter = &Flags{}
+
+// Get retrieves the value of a flag that was set. If the flag does not exist
+// or was not set, an empty string will be returned. The bool return value
+// indicates whether the flag was set.
+func (f *Flags) Get(key string) (string, bool) {
+	var val string
+	var found bool
+	flagSet(f.Set).Visit(func(fl *flag.Flag) {
+		if fl.Name == key {
+			val = fl.Value.String()
+			found = true
+		}
+	})
+	return val, found
+}
+
+// GetDefault retrieves the value of a flag that was set. If the flag does not
+// exist or was not set, then the default value will be returned.
+func (f *Flags) GetDefault(key, defVal string) string {
+	v, ok := f.Get(key)
+	if !ok {
+		return defVal
+	}
+	return v
+}
+
+// Names returns the names of all flags that were set.
+func (f *Flags) Names() []string {
+	result := make([]string, 0, 8)
+	flagSet(f.Set).Visit(func(fl *flag.Flag) {
+		result = append(result, fl.Name)
+	})
+	return result
+}
+
+// FlagDefaults reads properties from the default values of all flags defined
+// in a flag.FlagSet.
+type FlagDefaults struct {
+	// Set provides the flags to read. If nil, flag.CommandLine will be used.
+	Set *flag.FlagSet
+}
+
+// Ensure that FlagDefaults implements PropertyGetter
+var _ PropertyGetter = &FlagDefaults{}
+
+// Get retrieves the default value of a flag. If the flag does not exist, an
+// empty string will be returned. The bool return value indicates whether the
+// flag was found.
+func (f *FlagDefaults) Get(key string) (string, bool) {
+	fl := flagSet(f.Set).Lookup(key)
+	if fl == nil {
+		return "", false
+	}
+	return fl.DefValue, true
+}
+
+// GetDefault retrieves the default value of a flag. If the flag does not
+// exist, then the default value provided will be returned.
+func (f *FlagDefaults) GetDefault(key, defVal string) string {
+	v, ok := f.Get(key)
+	if !ok {
+		return defVal
+	}
+	return v
+}
+
+// Names returns the names of all defined flags.
+func (f *FlagDefaults) Names() []string {
+	result := make([]string, 0, 8)
+	flagSet(f.Set).VisitAll(func(fl *flag.Flag) {
+		result = append(result, fl.Name)
+	})
+	return result
+}
+
+// SetFlagDefaults changes the default value of every flag in the set that has
+// a matching property. Flags that have already been set are not changed. This
+// should be called before the flags are parsed so that command line values
+// take priority over property values; the new defaults are also shown in the
+// usage message.
+//
+// An error will be returned if a property value is not valid for its flag.
+func SetFlagDefaults(fs *flag.FlagSet, p PropertyGetter) error {
+	fs = flagSet(fs)
+	set := make(map[string]struct{})
+	fs.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = struct{}{}
+	})
+
+	var err error
+	fs.VisitAll(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		if _, ok := set[fl.Name]; ok {
+			return
+		}
+		val, ok := p.Get(fl.Name)
+		if !ok {
+			return
+		}
+		if setErr := fl.Value.Set(val); setErr != nil {
+			err = fmt.Errorf("invalid flag value %s=%s [%w]", fl.Name, val, setErr)
+			return
+		}
+		fl.DefValue = fl.Value.String()
+	})
+	return err
+}
+
+// flagSet returns the provided flag set or flag.CommandLine if it is nil.
+func flagSet(fs *flag.FlagSet) *flag.FlagSet {
+	if fs == nil {
+		return flag.CommandLine
+	}
+	return fs
+}
@@ -0,0 +1,141 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newTestFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Int("port", 8080, "port")
+	fs.String("host", "localhost", "host")
+	fs.Bool("verbose", false, "verbose")
+	return fs
+}
+
+func TestFlags(t *testing.T) {
+	fs := newTestFlags()
+	fs.Parse([]string{"-port", "9090", "-verbose"})
+	f := &Flags{Set: fs}
+
+	val, ok := f.Get("port")
+	if !ok || val != "9090" {
+		t.Errorf("want: true, '9090'; got: %t, '%s'", ok, val)
+	}
+	val, ok = f.Get("verbose")
+	if !ok || val != "true" {
+		t.Errorf("want: true, 'true'; got: %t, '%s'", ok, val)
+	}
+	val, ok = f.Get("host")
+	if ok || val != "" {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+	val, ok = f.Get("none")
+	if ok || val != "" {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+	if val = f.GetDefault("port", "1"); val != "9090" {
+		t.Errorf("want: '9090'; got: '%s'", val)
+	}
+	if val = f.GetDefault("host", "def"); val != "def" {
+		t.Errorf("want: 'def'; got: '%s'", val)
+	}
+
+	got := f.Names()
+	sort.Strings(got)
+	want := []string{"port", "verbose"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+func TestFlagDefaults(t *testing.T) {
+	fs := newTestFlags()
+	fs.Parse([]string{"-port", "9090"})
+	f := &FlagDefaults{Set: fs}
+
+	val, ok := f.Get("port")
+	if !ok || val != "8080" {
+		t.Errorf("want: true, '8080'; got: %t, '%s'", ok, val)
+	}
+	val, ok = f.Get("none")
+	if ok || val != "" {
+		t.Errorf("want: false, ''; got: %t, '%s'", ok, val)
+	}
+	if val = f.GetDefault("host", "def"); val != "localhost" {
+		t.Errorf("want: 'localhost'; got: '%s'", val)
+	}
+	if val = f.GetDefault("none", "def"); val != "def" {
+		t.Errorf("want: 'def'; got: '%s'", val)
+	}
+
+	got := f.Names()
+	want := []string{"host", "port", "verbose"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v; got: %v", want, got)
+	}
+}
+
+func TestFlagsPrecedence(t *testing.T) {
+	fs := newTestFlags()
+	fs.Parse([]string{"-port", "9090"})
+
+	p := NewProperties()
+	p.Set("port", "80")
+	p.Set("host", "example.com")
+
+	c := &Combined{Sources: []PropertyGetter{&Flags{Set: fs}, p, &FlagDefaults{Set: fs}}}
+	tests := map[string]string{"port": "9090", "host": "example.com", "verbose": "false"}
+	for k, want := range tests {
+		if got, _ := c.Get(k); got != want {
+			t.Errorf("%s want: '%s'; got: '%s'", k, want, got)
+		}
+	}
+}
+
+func TestSetFlagDefaults(t *testing.T) {
+	fs := newTestFlags()
+	fs.Set("host", "cmdline")
+
+	p := NewProperties()
+	p.Set("port", "80")
+	p.Set("host", "example.com")
+	p.Set("other", "x")
+
+	if err := SetFlagDefaults(fs, p); err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if f := fs.Lookup("port"); f.DefValue != "80" || f.Value.String() != "80" {
+		t.Errorf("want: '80', '80'; got: '%s', '%s'", f.DefValue, f.Value.String())
+	}
+	if f := fs.Lookup("host"); f.DefValue != "localhost" || f.Value.String() != "cmdline" {
+		t.Errorf("want: 'localhost', 'cmdline'; got: '%s', '%s'", f.DefValue, f.Value.String())
+	}
+
+	fs.Parse([]string{"-port", "9090"})
+	if got, _ := (&Flags{Set: fs}).Get("port"); got != "9090" {
+		t.Errorf("want: '9090'; got: '%s'", got)
+	}
+
+	p.Set("verbose", "maybe")
+	if err := SetFlagDefaults(newTestFlags(), p); err == nil {
+		t.Error("want: error; got: nil")
+	}
+}
+
+func TestFlagsCommandLine(t *testing.T) {
+	f := &Flags{}
+	if _, ok := f.Get("none"); ok {
+		t.Error("want: false; got: true")
+	}
+	d := &FlagDefaults{}
+	if _, ok := d.Get("none"); ok {
+		t.Error("want: false; got: true")
+	}
+}
@@ -2,6 +2,11 @@ module github.com/rickar/props
 
 go 1.20
 
-require golang.org/x/term v0.29.0
+require (
+	github.com/BurntSushi/toml v1.5.0
+	golang.org/x/crypto v0.33.0
+	golang.org/x/term v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require golang.org/x/sys v0.30.0 // indirect
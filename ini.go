@@ -0,0 +1,258 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReadINI creates a new property set and fills it with the contents of an INI
+// file. See LoadINI for the supported file format.
+func ReadINI(r io.Reader) (*Properties, error) {
+	p := NewProperties()
+	err := p.LoadINI(r)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+/*
+LoadINI reads the contents of an INI file. Existing properties will be
+retained. The contents of the file will override any existing properties with
+matching keys.
+
+# File Format
+
+Each line of the file is a "key=value" pair, a "[section]" header, a comment,
+or blank. Keys and values may be separated by '=' or ':' and whitespace around
+the key and value is ignored. Comments are lines starting with ';' or '#'.
+
+Keys following a section header are prefixed by the section name and '.'.
+Keys before the first header have no prefix. A section may appear more than
+once; the keys from each occurrence are combined.
+
+Values may be quoted with single or double quotes to keep leading or trailing
+whitespace or comment characters. Double quoted values support the escapes
+'\n', '\r', '\t', '\"', and '\\'. A comment may follow a value on the same line
+if the ';' or '#' is preceded by whitespace.
+
+# Sample File
+
+	; tool.ini
+	name = tool
+
+	[db]
+	host = localhost   ; comment
+	user = "  spaced  "
+
+	[server.http]
+	port: 8080
+
+	[db]
+	port = 5432
+
+Loading this file would result in the following properties:
+
+	"name":             "tool"
+	"db.host":          "localhost"
+	"db.user":          "  spaced  "
+	"server.http.port": "8080"
+	"db.port":          "5432"
+*/
+func (p *Properties) LoadINI(r io.Reader) error {
+	defer func() { p.version++ }()
+
+	l := p.loader()
+	section := ""
+	num := 0
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		num++
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			// section names may contain list indexes such as [servers[0]]
+			end := -1
+			for i := 1; i < len(line); i++ {
+				if line[i] != ']' {
+					continue
+				}
+				rest := strings.TrimSpace(line[i+1:])
+				if rest == "" || rest[0] == ';' || rest[0] == '#' {
+					end = i
+					break
+				}
+			}
+			if end < 0 {
+				return fmt.Errorf("invalid ini line %d: unterminated section", num)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return fmt.Errorf("invalid ini line %d: missing '='", num)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if key == "" {
+			return fmt.Errorf("invalid ini line %d: missing key", num)
+		}
+		val, err := iniValue(strings.TrimSpace(line[sep+1:]))
+		if err != nil {
+			return fmt.Errorf("invalid ini line %d: %w", num, err)
+		}
+		l.add(joinKey(section, key), val)
+	}
+	if err := scan.Err(); err != nil {
+		return err
+	}
+	return l.finish()
+}
+
+// iniValue converts the text following a key separator into a value by
+// removing quotes and comments.
+func iniValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	if s[0] == '"' || s[0] == '\'' {
+		quote := s[0]
+		var buf strings.Builder
+		for i := 1; i < len(s); i++ {
+			ch := s[i]
+			if ch == quote {
+				rest := strings.TrimSpace(s[i+1:])
+				if rest != "" && rest[0] != ';' && rest[0] != '#' {
+					return "", fmt.Errorf("unexpected text after quoted value")
+				}
+				return buf.String(), nil
+			}
+			if ch == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 't':
+					buf.WriteByte('\t')
+				case '"', '\\':
+					buf.WriteByte(s[i])
+				default:
+					buf.WriteByte('\\')
+					buf.WriteByte(s[i])
+				}
+				continue
+			}
+			buf.WriteByte(ch)
+		}
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i]), nil
+		}
+	}
+	return s, nil
+}
+
+// WriteINI saves the property set to a file in INI format. Each key is split
+// at its last '.' into a section name and a key within that section; keys with
+// no '.' are written before the first section. Sections and keys are written
+// in sorted order and values are quoted when needed. Properties with more than
+// one value are written once for each value. See LoadINI for more details on
+// the file format.
+//
+// An error will be returned if a key cannot be represented in an INI file,
+// such as one containing '=', ':', or a newline or one ending with '.'.
+//
+// Note: if the property set was loaded from a file, the formatting and
+// comments from the original file will not be retained in the output file.
+func (p *Properties) WriteINI(w io.Writer) error {
+	sections := make(map[string][]string)
+	for k := range p.values {
+		section, name := "", k
+		if i := strings.LastIndexByte(k, '.'); i >= 0 {
+			section, name = k[:i], k[i+1:]
+		}
+		if name == "" || strings.TrimSpace(name) != name || strings.ContainsAny(k, "=:\r\n") ||
+			name[0] == '[' || name[0] == ';' || name[0] == '#' {
+			return fmt.Errorf("invalid ini key %q", k)
+		}
+		sections[section] = append(sections[section], k)
+	}
+
+	names := make([]string, 0, len(sections))
+	for s := range sections {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for i, section := range names {
+		if section != "" {
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			fmt.Fprintf(bw, "[%s]\n", section)
+		}
+		keys := sections[section]
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := k
+			if section != "" {
+				name = k[len(section)+1:]
+			}
+			vals, ok := p.multi[k]
+			if !ok {
+				vals = []string{p.values[k]}
+			}
+			for _, v := range vals {
+				if v == "" {
+					fmt.Fprintf(bw, "%s =\n", name)
+				} else {
+					fmt.Fprintf(bw, "%s = %s\n", name, iniQuote(v))
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// iniQuote returns a value that is safe to write to an INI file, quoting and
+// escaping it if needed.
+func iniQuote(s string) string {
+	if !strings.ContainsAny(s, "\"'\\;#\r\n\t") && strings.TrimSpace(s) == s {
+		return s
+	}
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(s[i])
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,173 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var iniFile = `
+; comment
+# another comment
+name = tool
+top: colon
+
+[db]
+host = localhost   ; comment
+user = "  spaced  " ; comment
+pass = 'a;b#c\n'
+url = "x\"y\\z\n\t\r\q"
+hash = abc#def;ghi
+empty =
+eq = a=b
+
+[ server.http ] # comment
+port: 8080
+
+[servers[0]]
+host = a.example.com
+
+[db]
+port = 5432
+`
+
+func TestLoadINI(t *testing.T) {
+	p, err := ReadINI(strings.NewReader(iniFile))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	want := map[string]string{
+		"name":             "tool",
+		"top":              "colon",
+		"db.host":          "localhost",
+		"db.user":          "  spaced  ",
+		"db.pass":          `a;b#c\n`,
+		"db.url":           "x\"y\\z\n\t\r\\q",
+		"db.hash":          "abc#def;ghi",
+		"db.empty":         "",
+		"db.eq":            "a=b",
+		"db.port":          "5432",
+		"server.http.port": "8080",
+		"servers[0].host":  "a.example.com",
+	}
+	if !reflect.DeepEqual(want, p.values) {
+		t.Errorf("want: %v; got: %v", want, p.values)
+	}
+	if p.Version() == 0 {
+		t.Errorf("want: version change; got: none")
+	}
+}
+
+func TestLoadINIBad(t *testing.T) {
+	tests := []string{
+		"[db",
+		"[db] extra",
+		"novalue",
+		"= value",
+		`key = "unterminated`,
+		`key = "quoted" extra`,
+		`key = 'unterminated`,
+	}
+
+	for i, test := range tests {
+		_, err := ReadINI(strings.NewReader("a=b\n" + test))
+		if err == nil {
+			t.Errorf("%d want err; got none", i)
+		} else if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%d want: line 2 error; got: %v", i, err)
+		}
+	}
+
+	_, err := ReadINI(&ErrorReader{})
+	if err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestWriteINI(t *testing.T) {
+	p := NewProperties()
+	p.Set("name", "tool")
+	p.Set("db.host", "localhost")
+	p.Set("db.user", "  spaced  ")
+	p.Set("db.note", "a;b # \"c\"\n")
+	p.Set("db.empty", "")
+	p.Set("server.http.port", "8080")
+	p.Set("servers[0].host", "a.example.com")
+
+	var buf bytes.Buffer
+	err := p.WriteINI(&buf)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+
+	want := `name = tool
+
+[db]
+empty =
+host = localhost
+note = "a;b # \"c\"\n"
+user = "  spaced  "
+
+[server.http]
+port = 8080
+
+[servers[0]]
+host = a.example.com
+`
+	if buf.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	p2, err := ReadINI(&buf)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if !reflect.DeepEqual(p.values, p2.values) {
+		t.Errorf("want: %v; got: %v", p.values, p2.values)
+	}
+
+	for _, key := range []string{"a=b", "a:b", "a\nb", "a.", " a", "a. b", "[a", "a.;b", "#a", ""} {
+		p := NewProperties()
+		p.Set(key, "value")
+		if err := p.WriteINI(&buf); err == nil {
+			t.Errorf("%q want err; got none", key)
+		}
+	}
+
+	p = NewProperties()
+	p.Set("a", "b")
+	if err := p.WriteINI(&ErrorWriter{}); err == nil {
+		t.Errorf("want err; got none")
+	}
+}
+
+func TestINIDuplicates(t *testing.T) {
+	file := "[s]\na=1\n[t]\na=2\n[s]\na=3\n"
+
+	p := NewProperties()
+	p.Duplicates = DuplicateKeep
+	err := p.LoadINI(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if got := p.GetAll("s.a"); !reflect.DeepEqual(got, []string{"1", "3"}) {
+		t.Errorf("want: [1 3]; got: %v", got)
+	}
+
+	var buf bytes.Buffer
+	p.WriteINI(&buf)
+	if want := "[s]\na = 1\na = 3\n\n[t]\na = 2\n"; buf.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	p = NewProperties()
+	p.Duplicates = DuplicateError
+	err = p.LoadINI(strings.NewReader(file))
+	if dupErr, ok := err.(*DuplicateKeyError); !ok || !reflect.DeepEqual(dupErr.Keys, []string{"s.a"}) {
+		t.Errorf("want: duplicate s.a; got: %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpKeyClient is used by HTTPKey when no client is provided. Unlike
+// http.DefaultClient, it does not wait forever for an unresponsive service.
+var httpKeyClient = &http.Client{Timeout: 10 * time.Second}
+
+// KeyProvider supplies the passwords used to encrypt and decrypt property
+// values so that callers do not need to handle them directly.
+type KeyProvider interface {
+	// PrimaryKey returns the ID and password of the key to use for new
+	// values. The ID may be empty if the provider does not use key IDs.
+	PrimaryKey() (id, password string, err error)
+
+	// Key returns the password for the key ID. An empty ID is used for values
+	// that do not have a key ID.
+	Key(id string) (string, error)
+}
+
+// EncryptWith returns the value encrypted with the algorithm using the primary
+// key from the provider. If the key has an ID, it is added to the value's
+// marker. See Encrypt for details.
+func EncryptWith(p KeyProvider, alg, value string) (string, error) {
+	id, pass, err := primaryKey(p)
+	if err != nil {
+		return "", err
+	}
+	return encrypt(alg, id, pass, value, nil)
+}
+
+// EncryptBoundWith returns the value encrypted with the algorithm using the
+// primary key from the provider and bound to the property key and context.
+// See EncryptBound for details.
+func EncryptBoundWith(p KeyProvider, alg, value, key string, context ...string) (string, error) {
+	id, pass, err := primaryKey(p)
+	if err != nil {
+		return "", err
+	}
+	return encrypt(alg, id, pass, value, boundData(key, context))
+}
+
+// primaryKey gets the primary key from the provider and validates its ID.
+func primaryKey(p KeyProvider) (string, string, error) {
+	id, pass, err := p.PrimaryKey()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get encryption key [%w]", err)
+	}
+	if id != "" && !isKeyID(id) {
+		return "", "", fmt.Errorf("invalid key id %q", id)
+	}
+	return id, pass, nil
+}
+
+// DecryptWith returns the plaintext of a value encrypted with EncryptWith or
+// Encrypt using the key named in the value from the provider. See Decrypt for
+// details.
+func DecryptWith(p KeyProvider, val string) (string, error) {
+	dec, err := decryptWith(p, val, nil)
+	return string(dec), err
+}
+
+// DecryptBoundWith returns the plaintext of a value encrypted with
+// EncryptBoundWith or EncryptBound using the key named in the value from the
+// provider. See DecryptBound for details.
+func DecryptBoundWith(p KeyProvider, val, key string, context ...string) (string, error) {
+	dec, err := decryptWith(p, val, boundData(key, context))
+	return string(dec), err
+}
+
+// decryptWith gets the key for the value from the provider and decrypts it.
+func decryptWith(p KeyProvider, val string, data []byte) ([]byte, error) {
+	alg, id, _, err := decodeValue(val)
+	if err != nil || alg == EncryptNone {
+		return decrypt("", val, data)
+	}
+	pass, err := p.Key(id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get decryption key [%w]", err)
+	}
+	return decrypt(pass, val, data)
+}
+
+// singleKey implements Key for providers with a single key. If the provider
+// has no key ID, its key is used for every value.
+func singleKey(keyID, id string, get func() (string, error)) (string, error) {
+	if keyID != "" && id != "" && id != keyID {
+		return "", fmt.Errorf("unknown key id %s", id)
+	}
+	return get()
+}
+
+// EnvKey provides a single key from an environment variable.
+type EnvKey struct {
+	// Name is the environment variable that holds the password.
+	Name string
+
+	// ID is the optional key ID added to new values. If set, values with a
+	// different key ID are rejected.
+	ID string
+}
+
+// Ensure that EnvKey implements KeyProvider
+var _ KeyProvider = &EnvKey{}
+
+// PrimaryKey returns the ID and the password from the environment variable.
+func (e *EnvKey) PrimaryKey() (string, string, error) {
+	pass, err := e.password()
+	return e.ID, pass, err
+}
+
+// Key returns the password from the environment variable if ID is empty or
+// matches the id.
+func (e *EnvKey) Key(id string) (string, error) {
+	return singleKey(e.ID, id, e.password)
+}
+
+// password reads the environment variable.
+func (e *EnvKey) password() (string, error) {
+	pass, ok := os.LookupEnv(e.Name)
+	if !ok || pass == "" {
+		return "", fmt.Errorf("environment variable %s is not set", e.Name)
+	}
+	return pass, nil
+}
+
+// FileKey provides a single key from a file. Trailing whitespace, such as a
+// final newline, is removed from the password.
+//
+// On systems other than Windows, the file must not be readable or writable by
+// group or other users (for example, mode 0600 or 0400).
+type FileKey struct {
+	// Path is the name of the file that holds the password.
+	Path string
+
+	// ID is the optional key ID added to new values. If set, values with a
+	// different key ID are rejected.
+	ID string
+}
+
+// Ensure that FileKey implements KeyProvider
+var _ KeyProvider = &FileKey{}
+
+// PrimaryKey returns the ID and the password from the file.
+func (f *FileKey) PrimaryKey() (string, string, error) {
+	pass, err := f.password()
+	return f.ID, pass, err
+}
+
+// Key returns the password from the file if ID is empty or matches the id.
+func (f *FileKey) Key(id string) (string, error) {
+	return singleKey(f.ID, id, f.password)
+}
+
+// password checks the permissions on the file and reads it.
+func (f *FileKey) password() (string, error) {
+	stat, err := os.Stat(f.Path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && stat.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("key file %s must not be accessible by group or others (mode %04o)", f.Path, stat.Mode().Perm())
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", err
+	}
+	pass := strings.TrimRight(string(data), " \t\r\n")
+	if pass == "" {
+		return "", fmt.Errorf("key file %s is empty", f.Path)
+	}
+	return pass, nil
+}
+
+// FDKey provides a single key read from an open file descriptor, such as a
+// pipe created by a parent process with "3<<<$KEY". The descriptor is read
+// to the end and closed the first time a key is needed. Trailing whitespace is
+// removed from the password.
+type FDKey struct {
+	// FD is the file descriptor to read the password from.
+	FD uintptr
+
+	// ID is the optional key ID added to new values. If set, values with a
+	// different key ID are rejected.
+	ID string
+
+	once sync.Once
+	pass string
+	err  error
+}
+
+// Ensure that FDKey implements KeyProvider
+var _ KeyProvider = &FDKey{}
+
+// PrimaryKey returns the ID and the password from the file descriptor.
+func (f *FDKey) PrimaryKey() (string, string, error) {
+	pass, err := f.password()
+	return f.ID, pass, err
+}
+
+// Key returns the password from the file descriptor if ID is empty or matches
+// the id.
+func (f *FDKey) Key(id string) (string, error) {
+	return singleKey(f.ID, id, f.password)
+}
+
+// password reads the file descriptor once.
+func (f *FDKey) password() (string, error) {
+	f.once.Do(func() {
+		file := os.NewFile(f.FD, "key")
+		if file == nil {
+			f.err = fmt.Errorf("invalid key file descriptor %d", f.FD)
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			f.err = fmt.Errorf("unable to read key file descriptor %d [%w]", f.FD, err)
+			return
+		}
+		f.pass = strings.TrimRight(string(data), " \t\r\n")
+		if f.pass == "" {
+			f.err = fmt.Errorf("key file descriptor %d is empty", f.FD)
+		}
+	})
+	return f.pass, f.err
+}
+
+// HTTPKey provides keys from an HTTP service using a simple KMS-style
+// protocol. The primary key is requested with:
+//
+//	GET <URL>/primary
+//
+// and a key by ID is requested with:
+//
+//	GET <URL>/keys/<id>
+//
+// Both return a JSON object with the key ID and password:
+//
+//	{"id": "k2025", "key": "password"}
+//
+// Any status other than 200 OK is an error.
+type HTTPKey struct {
+	// URL is the base URL of the key service.
+	URL string
+
+	// Token is sent as a bearer token in the Authorization header if set.
+	Token string
+
+	// Client is used to make requests. If nil, a client with a 10 second
+	// timeout is used.
+	Client *http.Client
+}
+
+// Ensure that HTTPKey implements KeyProvider
+var _ KeyProvider = &HTTPKey{}
+
+// httpKeyResponse is the body returned by the key service.
+type httpKeyResponse struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// PrimaryKey requests the primary key from the service.
+func (h *HTTPKey) PrimaryKey() (string, string, error) {
+	resp, err := h.get("/primary")
+	if err != nil {
+		return "", "", err
+	}
+	return resp.ID, resp.Key, nil
+}
+
+// Key requests the key with the ID from the service. If the ID is empty, the
+// primary key is returned. An error is returned if the service responds with
+// a different key ID.
+func (h *HTTPKey) Key(id string) (string, error) {
+	if id == "" {
+		_, pass, err := h.PrimaryKey()
+		return pass, err
+	}
+	resp, err := h.get("/keys/" + url.PathEscape(id))
+	if err != nil {
+		return "", err
+	}
+	if resp.ID != id {
+		return "", fmt.Errorf("key service returned key id %q for %s", resp.ID, id)
+	}
+	return resp.Key, nil
+}
+
+// get requests a key from the service.
+func (h *HTTPKey) get(path string) (*httpKeyResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(h.URL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = httpKeyClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key service returned %s for %s", res.Status, path)
+	}
+
+	var resp httpKeyResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key service response [%w]", err)
+	}
+	if resp.Key == "" {
+		return nil, fmt.Errorf("key service returned no key for %s", path)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,191 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvKey(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	t.Setenv("PROPS_TEST_KEY", "env pass")
+	kp := &EnvKey{Name: "PROPS_TEST_KEY", ID: "env1"}
+	enc, err := EncryptWith(kp, EncryptXChaCha20, "value")
+	if !strings.HasPrefix(enc, "[enc:3:env1]") || err != nil {
+		t.Fatalf("want: '[enc:3:env1]...', err == nil; got: %s, %v", enc, err)
+	}
+	dec, err := DecryptWith(kp, enc)
+	if dec != "value" || err != nil {
+		t.Errorf("want: 'value', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = DecryptWith(&EnvKey{Name: "PROPS_TEST_KEY", ID: "env2"}, enc)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+
+	dec, err = DecryptWith(&EnvKey{Name: "PROPS_TEST_KEY"}, enc)
+	if dec != "value" || err != nil {
+		t.Errorf("want: 'value', err == nil; got: %s, %v", dec, err)
+	}
+
+	legacy, _ := Encrypt(EncryptAESGCMPBKDF2, "env pass", "legacy")
+	dec, err = DecryptWith(kp, legacy)
+	if dec != "legacy" || err != nil {
+		t.Errorf("want: 'legacy', err == nil; got: %s, %v", dec, err)
+	}
+
+	t.Setenv("PROPS_TEST_KEY", "")
+	_, err = EncryptWith(kp, EncryptXChaCha20, "value")
+	if err == nil {
+		t.Errorf("want: err != nil for empty variable; got: nil")
+	}
+	_, err = DecryptWith(&EnvKey{Name: "PROPS_TEST_KEY_MISSING"}, legacy)
+	if err == nil {
+		t.Errorf("want: err != nil for missing variable; got: nil")
+	}
+
+	dec, err = DecryptWith(kp, "[enc:0]plain")
+	if dec != "plain" || err != nil {
+		t.Errorf("want: 'plain', err == nil; got: %s, %v", dec, err)
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.key")
+	if err := os.WriteFile(good, []byte("file pass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kp := &FileKey{Path: good}
+	enc, err := EncryptBoundWith(kp, EncryptAESGCMBound, "value", "db.password")
+	if !strings.HasPrefix(enc, "[enc:4]") || err != nil {
+		t.Fatalf("want: '[enc:4]...', err == nil; got: %s, %v", enc, err)
+	}
+	dec, err := DecryptBound("file pass", enc, "db.password")
+	if dec != "value" || err != nil {
+		t.Errorf("want: 'value', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = DecryptBoundWith(kp, enc, "db.password")
+	if dec != "value" || err != nil {
+		t.Errorf("want: 'value', err == nil; got: %s, %v", dec, err)
+	}
+
+	if runtime.GOOS != "windows" {
+		open := filepath.Join(dir, "open.key")
+		if err := os.WriteFile(open, []byte("file pass\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(open, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = DecryptBoundWith(&FileKey{Path: open}, enc, "db.password")
+		if err == nil || !strings.Contains(err.Error(), "group or others") {
+			t.Errorf("want: permission error; got: %v", err)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.key")
+	if err := os.WriteFile(empty, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = DecryptBoundWith(&FileKey{Path: empty}, enc, "db.password")
+	if err == nil {
+		t.Errorf("want: err != nil for empty file; got: nil")
+	}
+	_, err = DecryptBoundWith(&FileKey{Path: filepath.Join(dir, "missing.key")}, enc, "db.password")
+	if err == nil {
+		t.Errorf("want: err != nil for missing file; got: nil")
+	}
+}
+
+func TestHTTPKey(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	primary := `{"id": "k2", "key": "new pass"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/primary":
+			w.Write([]byte(primary))
+		case "/v1/keys/k1":
+			w.Write([]byte(`{"id": "k1", "key": "old pass"}`))
+		case "/v1/keys/k2":
+			w.Write([]byte(`{"id": "k2", "key": "new pass"}`))
+		case "/v1/keys/bad":
+			w.Write([]byte(`{"id": "bad"`))
+		case "/v1/keys/nokey":
+			w.Write([]byte(`{"id": "nokey"}`))
+		case "/v1/keys/other":
+			w.Write([]byte(`{"id": "k2", "key": "new pass"}`))
+		case "/v1/keys/slow":
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	kp := &HTTPKey{URL: srv.URL + "/v1/", Token: "token", Client: srv.Client()}
+	enc, err := EncryptWith(kp, EncryptXChaCha20, "new value")
+	if !strings.HasPrefix(enc, "[enc:3:k2]") || err != nil {
+		t.Fatalf("want: '[enc:3:k2]...', err == nil; got: %s, %v", enc, err)
+	}
+	old, _ := (&Keyring{Primary: "k1", Keys: map[string]string{"k1": "old pass"}}).Encrypt(EncryptXChaCha20, "old value")
+	legacy, _ := Encrypt(EncryptXChaCha20, "new pass", "legacy value")
+
+	tests := map[string]string{
+		enc:    "new value",
+		old:    "old value",
+		legacy: "legacy value",
+	}
+	for val, want := range tests {
+		dec, err := DecryptWith(kp, val)
+		if dec != want || err != nil {
+			t.Errorf("%s want: '%s', err == nil; got: %s, %v", val, want, dec, err)
+		}
+	}
+
+	for _, id := range []string{"k3", "bad", "nokey", "other"} {
+		val := "[enc:3:" + id + "]" + enc[len("[enc:3:k2]"):]
+		dec, err := DecryptWith(kp, val)
+		if dec != "" || err == nil {
+			t.Errorf("%s want: '', err != nil; got: %s, %v", id, dec, err)
+		}
+	}
+
+	_, err = DecryptWith(&HTTPKey{URL: srv.URL + "/v1", Client: srv.Client()}, enc)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("want: unauthorized error; got: %v", err)
+	}
+
+	primary = `{"id": "bad:id", "key": "new pass"}`
+	_, err = EncryptWith(kp, EncryptXChaCha20, "value")
+	if err == nil {
+		t.Errorf("want: err != nil for invalid key id; got: nil")
+	}
+
+	// the default client does not wait forever
+	defer func(c *http.Client) { httpKeyClient = c }(httpKeyClient)
+	httpKeyClient = &http.Client{Timeout: 50 * time.Millisecond}
+	_, err = (&HTTPKey{URL: srv.URL + "/v1", Token: "token"}).Key("slow")
+	if err == nil {
+		t.Errorf("want: err != nil for timeout; got: nil")
+	}
+}
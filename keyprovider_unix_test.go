@@ -0,0 +1,53 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+//go:build unix
+
+package props
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// pipeFD returns a duplicate of the read end of a pipe containing data. The
+// duplicate is owned by the caller (an FDKey) and the original is closed.
+func pipeFD(t *testing.T, data string) uintptr {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	defer r.Close()
+
+	fd, err := syscall.Dup(int(r.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uintptr(fd)
+}
+
+func TestFDKey(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	kp := &FDKey{FD: pipeFD(t, "pipe pass\n"), ID: "p1"}
+	enc, err := EncryptWith(kp, EncryptAESGCMPBKDF2, "value")
+	if !strings.HasPrefix(enc, "[enc:2:p1]") || err != nil {
+		t.Fatalf("want: '[enc:2:p1]...', err == nil; got: %s, %v", enc, err)
+	}
+	// the key is remembered after the pipe is read and closed
+	dec, err := DecryptWith(kp, enc)
+	if dec != "value" || err != nil {
+		t.Errorf("want: 'value', err == nil; got: %s, %v", dec, err)
+	}
+
+	_, err = DecryptWith(&FDKey{FD: pipeFD(t, ""), ID: "p1"}, enc)
+	if err == nil {
+		t.Errorf("want: err != nil for empty pipe; got: nil")
+	}
+}
@@ -0,0 +1,147 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Keyring holds several named encryption keys to allow keys to be rotated
+// gradually. New values are always encrypted with the primary key and the key
+// ID is added to the value's marker, such as "[enc:2:k2024]". Values are
+// decrypted with the key named in their marker.
+//
+// For example, to rotate from key k2024 to k2025, add k2025 to the keyring and
+// make it the primary key. Existing values continue to decrypt with k2024
+// while new or re-encrypted values use k2025. Once no values use k2024, it can
+// be removed.
+//
+//	kr := &Keyring{
+//		Primary: "k2025",
+//		Keys: map[string]string{
+//			"k2024": oldPassword,
+//			"k2025": newPassword,
+//		},
+//	}
+type Keyring struct {
+	// Primary is the ID of the key used to encrypt new values.
+	Primary string
+
+	// Keys holds the password for each key ID. IDs may contain letters,
+	// digits, '-', '_', and '.'.
+	Keys map[string]string
+}
+
+// Ensure that Keyring implements KeyProvider
+var _ KeyProvider = &Keyring{}
+
+// PrimaryKey returns the ID and password of the primary key. An error will be
+// returned if the primary key does not exist or its ID is not valid.
+func (k *Keyring) PrimaryKey() (string, string, error) {
+	if !isKeyID(k.Primary) {
+		return "", "", fmt.Errorf("invalid primary key id %q", k.Primary)
+	}
+	pass, err := k.Key(k.Primary)
+	if err != nil {
+		return "", "", err
+	}
+	return k.Primary, pass, nil
+}
+
+// Key returns the password for the key ID or the primary key if the ID is
+// empty. An error will be returned if the key does not exist.
+func (k *Keyring) Key(id string) (string, error) {
+	if id == "" {
+		id = k.Primary
+	}
+	pass, ok := k.Keys[id]
+	if !ok {
+		return "", fmt.Errorf("unknown key id %s", id)
+	}
+	return pass, nil
+}
+
+// Encrypt returns the value encrypted with the algorithm using the primary
+// key. See Encrypt for details.
+func (k *Keyring) Encrypt(alg, value string) (string, error) {
+	return EncryptWith(k, alg, value)
+}
+
+// EncryptBound returns the value encrypted with the algorithm using the
+// primary key and bound to the property key and context. See EncryptBound for
+// details.
+func (k *Keyring) EncryptBound(alg, value, key string, context ...string) (string, error) {
+	return EncryptBoundWith(k, alg, value, key, context...)
+}
+
+// Decrypt returns the plaintext of a value encrypted with Encrypt. The key
+// named in the value is used; values without a key ID are tried with the
+// primary key followed by the other keys in order by ID.
+func (k *Keyring) Decrypt(val string) (string, error) {
+	dec, err := k.decrypt(val, nil)
+	return string(dec), err
+}
+
+// DecryptBound returns the plaintext of a value encrypted with EncryptBound
+// using the same property key and context. Keys are selected as described by
+// Decrypt.
+func (k *Keyring) DecryptBound(val, key string, context ...string) (string, error) {
+	dec, err := k.decrypt(val, boundData(key, context))
+	return string(dec), err
+}
+
+// decrypt finds the key for the value and decrypts it.
+func (k *Keyring) decrypt(val string, data []byte) ([]byte, error) {
+	alg, id, _, err := decodeValue(val)
+	if err != nil || alg == EncryptNone || id != "" {
+		return decryptWith(k, val, data)
+	}
+
+	ids := make([]string, 0, len(k.Keys))
+	for id := range k.Keys {
+		if id != k.Primary {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if _, ok := k.Keys[k.Primary]; ok {
+		ids = append([]string{k.Primary}, ids...)
+	}
+
+	err = fmt.Errorf("no keys available")
+	for _, id := range ids {
+		var dec []byte
+		dec, err = decrypt(k.Keys[id], val, data)
+		if err == nil {
+			return dec, nil
+		}
+	}
+	return nil, err
+}
+
+// KeyID returns the key ID in an encrypted value's marker, such as "k2024"
+// for "[enc:2:k2024]...", or an empty string if there is none.
+func KeyID(val string) string {
+	i := strings.IndexByte(val, ']')
+	if i < 0 {
+		return ""
+	}
+	_, id := parseMarker(val[:i+1])
+	return id
+}
+
+// isKeyID determines whether the ID can be used in a value's marker.
+func isKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, ch := range id {
+		if !(ch >= 'a' && ch <= 'z') && !(ch >= 'A' && ch <= 'Z') && !(ch >= '0' && ch <= '9') &&
+			ch != '-' && ch != '_' && ch != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	old := &Keyring{Primary: "k2024", Keys: map[string]string{"k2024": "old pass"}}
+	oldVal, err := old.Encrypt(EncryptAESGCMPBKDF2, "old value")
+	if !strings.HasPrefix(oldVal, "[enc:2:k2024]") || err != nil {
+		t.Fatalf("want: '[enc:2:k2024]...', err == nil; got: %s, %v", oldVal, err)
+	}
+	legacy, _ := Encrypt(EncryptXChaCha20, "old pass", "legacy value")
+
+	kr := &Keyring{
+		Primary: "k2025",
+		Keys:    map[string]string{"k2024": "old pass", "k2025": "new pass"},
+	}
+	newVal, err := kr.Encrypt(EncryptXChaCha20, "new value")
+	if !strings.HasPrefix(newVal, "[enc:3:k2025]") || err != nil {
+		t.Fatalf("want: '[enc:3:k2025]...', err == nil; got: %s, %v", newVal, err)
+	}
+
+	tests := map[string]string{
+		oldVal:            "old value",
+		newVal:            "new value",
+		legacy:            "legacy value",
+		"[enc:0]plain":    "plain",
+		"[enc:0:k1]plain": "plain",
+	}
+	for val, want := range tests {
+		dec, err := kr.Decrypt(val)
+		if dec != want || err != nil {
+			t.Errorf("%s want: '%s', err == nil; got: %s, %v", val, want, dec, err)
+		}
+	}
+
+	// package functions ignore the key id
+	dec, err := Decrypt("new pass", newVal)
+	if dec != "new value" || err != nil {
+		t.Errorf("want: 'new value', err == nil; got: %s, %v", dec, err)
+	}
+
+	bad := []string{
+		"[enc:2:k2023]" + oldVal[len("[enc:2:k2024]"):],
+		"[enc:3:k2024]" + newVal[len("[enc:3:k2025]"):],
+		"[enc:9:k2024]AAAA",
+		"[enc:2:k2024]$$$$",
+		"nomarker",
+	}
+	for _, val := range bad {
+		dec, err := kr.Decrypt(val)
+		if dec != "" || err == nil {
+			t.Errorf("%s want: '', err != nil; got: %s, %v", val, dec, err)
+		}
+	}
+
+	dec, err = (&Keyring{}).Decrypt(legacy)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+
+	for _, primary := range []string{"", "missing", "bad:id", "bad]id"} {
+		kr := &Keyring{Primary: primary, Keys: map[string]string{primary: "pass"}}
+		if primary == "missing" {
+			delete(kr.Keys, primary)
+		}
+		val, err := kr.Encrypt(EncryptAESGCMPBKDF2, "value")
+		if val != "" || err == nil {
+			t.Errorf("%q want: '', err != nil; got: %s, %v", primary, val, err)
+		}
+		val, err = kr.EncryptBound(EncryptAESGCMBound, "value", "key")
+		if val != "" || err == nil {
+			t.Errorf("%q want: '', err != nil; got: %s, %v", primary, val, err)
+		}
+	}
+}
+
+func TestKeyringBound(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	kr := &Keyring{Primary: "a.1", Keys: map[string]string{"a.1": "pass"}}
+	val, err := kr.EncryptBound(EncryptAESGCMBound, "secret", "db.password", "prod")
+	if !strings.HasPrefix(val, "[enc:4:a.1]") || err != nil {
+		t.Fatalf("want: '[enc:4:a.1]...', err == nil; got: %s, %v", val, err)
+	}
+
+	dec, err := kr.DecryptBound(val, "db.password", "prod")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = kr.DecryptBound(val, "admin.password", "prod")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil; got: %s, %v", dec, err)
+	}
+	dec, err = DecryptBound("pass", val, "db.password", "prod")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	tests := map[string]string{
+		"[enc:2:k2024]AAAA": "k2024",
+		"[enc:2]AAAA":       "",
+		"[enc:0:k1]plain":   "k1",
+		"[enc:acme:x]AAAA":  "x",
+		"nomarker":          "",
+		"":                  "",
+	}
+	for val, want := range tests {
+		if got := KeyID(val); got != want {
+			t.Errorf("%s want: '%s'; got: '%s'", val, want, got)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 	"unicode"
 )
 
@@ -25,13 +26,75 @@ type PropertyGetter interface {
 	Names() []string
 }
 
+// Versioned represents a property source that can report when its contents
+// have changed.
+type Versioned interface {
+	// Version returns a number that changes whenever the property values
+	// change.
+	Version() uint64
+}
+
+// MultiGetter represents a property source that can have more than one value
+// for a key.
+type MultiGetter interface {
+	// GetAll retrieves every value of a property in the order they were
+	// added. If the property does not exist, nil will be returned.
+	GetAll(key string) []string
+}
+
+// DuplicateMode determines how a key that appears more than once in a single
+// file is handled when the file is loaded.
+type DuplicateMode int
+
+const (
+	// DuplicateReplace keeps only the last value for the key. This is the
+	// default.
+	DuplicateReplace DuplicateMode = iota
+
+	// DuplicateKeep records every value for the key. Get returns the last
+	// value and GetAll returns all of them.
+	DuplicateKeep
+
+	// DuplicateError keeps only the last value for the key (as with
+	// DuplicateReplace) but a *DuplicateKeyError listing the repeated keys is
+	// returned after the whole file has been loaded. The error may be treated
+	// as a warning since the property set is still complete.
+	DuplicateError
+)
+
+// DuplicateKeyError reports the keys that appeared more than once in a file
+// loaded with DuplicateError.
+type DuplicateKeyError struct {
+	// Keys contains each repeated key once in the order they were found.
+	Keys []string
+}
+
+// Error returns a message listing the repeated keys.
+func (e *DuplicateKeyError) Error() string {
+	return "duplicate property keys: " + strings.Join(e.Keys, ", ")
+}
+
 // Properties represents a set of key-value pairs.
 type Properties struct {
+	// Duplicates determines how keys that appear more than once in a file are
+	// handled by Load, LoadINI, and LoadDotEnv.
+	Duplicates DuplicateMode
+
 	values map[string]string
+
+	// multi holds every value for keys with more than one value
+	multi map[string][]string
+
+	// version is incremented for every change to values
+	version uint64
 }
 
-// Ensure that Properties implements PropertyGetter
-var _ PropertyGetter = &Properties{}
+// Ensure that Properties implements PropertyGetter, MultiGetter, and Versioned
+var (
+	_ PropertyGetter = &Properties{}
+	_ MultiGetter    = &Properties{}
+	_ Versioned      = &Properties{}
+)
 
 // NewProperties creates a new, empty property set.
 func NewProperties() *Properties {
@@ -68,14 +131,59 @@ func (p *Properties) GetDefault(key, defVal string) string {
 	return defVal
 }
 
-// Set adds or changes the value of a property.
+// GetAll retrieves every value of a property. A property will only have more
+// than one value if it was added with Add or loaded with DuplicateKeep. If the
+// property does not exist, nil will be returned.
+func (p *Properties) GetAll(key string) []string {
+	if vals, ok := p.multi[key]; ok {
+		return append([]string(nil), vals...)
+	}
+	if v, ok := p.values[key]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+// Set adds or changes the value of a property. Any other values for the
+// property are removed.
 func (p *Properties) Set(key, val string) {
 	p.values[key] = val
+	delete(p.multi, key)
+	p.version++
+}
+
+// Add adds another value for a property while keeping any existing values.
+// Get will return the added value and GetAll will return all values.
+func (p *Properties) Add(key, val string) {
+	p.add(key, val)
+	p.version++
+}
+
+// add appends a value for a property without changing the version.
+func (p *Properties) add(key, val string) {
+	if prev, ok := p.values[key]; ok {
+		if p.multi == nil {
+			p.multi = make(map[string][]string)
+		}
+		if _, ok := p.multi[key]; !ok {
+			p.multi[key] = []string{prev}
+		}
+		p.multi[key] = append(p.multi[key], val)
+	}
+	p.values[key] = val
 }
 
 // Clear removes all key-value pairs.
 func (p *Properties) Clear() {
 	p.values = make(map[string]string)
+	p.multi = nil
+	p.version++
+}
+
+// Version returns a number that changes whenever the property set is
+// modified.
+func (p *Properties) Version() uint64 {
+	return p.version
 }
 
 /*
@@ -151,7 +259,9 @@ Loading this file would result in the following properties:
 */
 func (p *Properties) Load(r io.Reader) error {
 	state := stateNone
-	s := &scanner{p: p}
+	l := p.loader()
+	s := &scanner{p: p, load: l}
+	defer func() { p.version++ }()
 
 	buf := bufio.NewReader(r)
 	for {
@@ -159,7 +269,7 @@ func (p *Properties) Load(r io.Reader) error {
 		if err != nil {
 			if err == io.EOF {
 				s.done()
-				return nil
+				return l.finish()
 			} else {
 				return err
 			}
@@ -168,6 +278,47 @@ func (p *Properties) Load(r io.Reader) error {
 	}
 }
 
+// loader adds the entries read by a single call to one of the Load methods
+// according to the Duplicates mode.
+type loader struct {
+	p    *Properties
+	seen map[string]int
+	dups []string
+}
+
+// loader creates a loader for the property set.
+func (p *Properties) loader() *loader {
+	return &loader{p: p, seen: make(map[string]int)}
+}
+
+// add sets the value of a property read from a file.
+func (l *loader) add(key, val string) {
+	l.seen[key]++
+	if l.seen[key] == 1 {
+		// the first value in a file replaces any values already in the set
+		l.p.values[key] = val
+		delete(l.p.multi, key)
+		return
+	}
+
+	if l.seen[key] == 2 {
+		l.dups = append(l.dups, key)
+	}
+	if l.p.Duplicates == DuplicateKeep {
+		l.p.add(key, val)
+	} else {
+		l.p.values[key] = val
+	}
+}
+
+// finish returns a *DuplicateKeyError if needed once the whole file is read.
+func (l *loader) finish() error {
+	if l.p.Duplicates == DuplicateError && len(l.dups) > 0 {
+		return &DuplicateKeyError{Keys: l.dups}
+	}
+	return nil
+}
+
 // Names returns the keys for all properties in the set.
 func (p *Properties) Names() []string {
 	names := make([]string, 0, len(p.values))
@@ -181,15 +332,23 @@ func (p *Properties) Names() []string {
 // format, with appropriate characters escaped. See Load for more details on
 // the file format.
 //
+// Properties with more than one value are written once for each value.
+//
 // Note: if the property set was loaded from a file, the formatting and
 // comments from the original file will not be retained in the output file.
 func (p *Properties) Write(w io.Writer) error {
 	for k, v := range p.values {
-		line := fmt.Sprintf("%s=%s\n", escape(k, true),
-			escape(v, false))
-		_, err := io.WriteString(w, line)
-		if err != nil {
-			return err
+		vals, ok := p.multi[k]
+		if !ok {
+			vals = []string{v}
+		}
+		for _, v := range vals {
+			line := fmt.Sprintf("%s=%s\n", escape(k, true),
+				escape(v, false))
+			_, err := io.WriteString(w, line)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
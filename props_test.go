@@ -313,3 +313,134 @@ func TestWriteError(t *testing.T) {
 		t.Errorf("want err; got none")
 	}
 }
+
+func TestVersion(t *testing.T) {
+	p := NewProperties()
+	v := p.Version()
+
+	p.Set("key", "val")
+	if p.Version() == v {
+		t.Error("want: version change after Set")
+	}
+	v = p.Version()
+
+	p.Load(bytes.NewBufferString("key2=val2"))
+	if p.Version() == v {
+		t.Error("want: version change after Load")
+	}
+	v = p.Version()
+
+	p.Clear()
+	if p.Version() == v {
+		t.Error("want: version change after Clear")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	file := "a=1\nb=2\na=3\nc=4\na=5\nb=6\n"
+
+	p := NewProperties()
+	p.Set("a", "0")
+	p.Add("c", "x")
+	p.Add("c", "y")
+	err := p.Load(bytes.NewBufferString(file))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	if v, _ := p.Get("a"); v != "5" {
+		t.Errorf("want: 5; got: %s", v)
+	}
+	if got := p.GetAll("a"); !reflect.DeepEqual(got, []string{"5"}) {
+		t.Errorf("want: [5]; got: %v", got)
+	}
+	if got := p.GetAll("c"); !reflect.DeepEqual(got, []string{"4"}) {
+		t.Errorf("want: [4]; got: %v", got)
+	}
+
+	p = NewProperties()
+	p.Duplicates = DuplicateKeep
+	p.Set("a", "0")
+	err = p.Load(bytes.NewBufferString(file))
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	want := map[string][]string{
+		"a": {"1", "3", "5"},
+		"b": {"2", "6"},
+		"c": {"4"},
+		"d": nil,
+	}
+	for k, v := range want {
+		if got := p.GetAll(k); !reflect.DeepEqual(got, v) {
+			t.Errorf("%s want: %v; got: %v", k, v, got)
+		}
+	}
+	if v, _ := p.Get("a"); v != "5" {
+		t.Errorf("want: 5; got: %s", v)
+	}
+	p.GetAll("a")[0] = "changed"
+	if got := p.GetAll("a"); got[0] != "1" {
+		t.Errorf("want: 1; got: %s", got[0])
+	}
+
+	buf := new(bytes.Buffer)
+	p.Write(buf)
+	p2 := NewProperties()
+	p2.Duplicates = DuplicateKeep
+	p2.Load(buf)
+	for k, v := range want {
+		if got := p2.GetAll(k); !reflect.DeepEqual(got, v) {
+			t.Errorf("%s want: %v; got: %v", k, v, got)
+		}
+	}
+
+	p.Set("a", "new")
+	if got := p.GetAll("a"); !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("want: [new]; got: %v", got)
+	}
+	p.Clear()
+	if got := p.GetAll("b"); got != nil {
+		t.Errorf("want: nil; got: %v", got)
+	}
+
+	p = NewProperties()
+	p.Duplicates = DuplicateError
+	err = p.Load(bytes.NewBufferString(file))
+	dupErr, ok := err.(*DuplicateKeyError)
+	if !ok {
+		t.Fatalf("want: *DuplicateKeyError; got: %v", err)
+	}
+	if !reflect.DeepEqual(dupErr.Keys, []string{"a", "b"}) {
+		t.Errorf("want: [a b]; got: %v", dupErr.Keys)
+	}
+	if dupErr.Error() != "duplicate property keys: a, b" {
+		t.Errorf("want: message; got: %s", dupErr.Error())
+	}
+	if v, _ := p.Get("b"); v != "6" {
+		t.Errorf("want: 6; got: %s", v)
+	}
+
+	err = p.Load(bytes.NewBufferString("a=1\nb=2\n"))
+	if err != nil {
+		t.Errorf("want: no error; got: %v", err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	p := NewProperties()
+	v := p.Version()
+	p.Add("a", "1")
+	p.Add("a", "2")
+	if p.Version() == v {
+		t.Error("want: version change after Add")
+	}
+	if got := p.GetAll("a"); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("want: [1 2]; got: %v", got)
+	}
+	if val, _ := p.Get("a"); val != "2" {
+		t.Errorf("want: 2; got: %s", val)
+	}
+	if names := p.Names(); !reflect.DeepEqual(names, []string{"a"}) {
+		t.Errorf("want: [a]; got: %v", names)
+	}
+}
@@ -0,0 +1,141 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// x25519Info is the HKDF info string for keys derived from an X25519
+	// secret
+	x25519Info = "props x25519 xchacha20-poly1305"
+	// x25519KeySize is the size of an X25519 public key in bytes
+	x25519KeySize = 32
+)
+
+// GenerateX25519Key returns a new key pair for EncryptX25519 and
+// EncryptX25519Bound as base64 strings. The public key is used as the
+// password to encrypt values and only the private key can decrypt them, so
+// developers and build pipelines can encrypt values without holding the key
+// used in production.
+func GenerateX25519Key() (public string, private string, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeKey(key.PublicKey().Bytes()), encodeKey(key.Bytes()), nil
+}
+
+// X25519PublicKey returns the public key for a private key created by
+// GenerateX25519Key.
+func X25519PublicKey(private string) (string, error) {
+	key, err := parseX25519Private(private)
+	if err != nil {
+		return "", err
+	}
+	return encodeKey(key.PublicKey().Bytes()), nil
+}
+
+// x25519Cipher implements public key encryption with an ephemeral X25519 key
+// agreement and XChaCha20-Poly1305. The encrypted value starts with the
+// ephemeral public key. The password is the recipient's public key to encrypt
+// and private key to decrypt.
+type x25519Cipher struct{}
+
+// encrypt creates an ephemeral key, derives the shared key, and encrypts the
+// plaintext with the additional data.
+func (c x25519Cipher) encrypt(password string, plaintext, data []byte) ([]byte, error) {
+	pub, err := decodeKey(password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x25519 public key")
+	}
+	recipient, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x25519 public key")
+	}
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := append(eph.PublicKey().Bytes(), recipient.Bytes()...)
+	aead, err := x25519AEAD(eph, recipient, salt)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, eph.PublicKey().Bytes(), plaintext, data), nil
+}
+
+// decrypt derives the shared key from the private key and the ephemeral
+// public key in the value and decrypts it with the additional data.
+func (c x25519Cipher) decrypt(password string, ciphertext, data []byte) ([]byte, error) {
+	key, err := parseX25519Private(password)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < x25519KeySize {
+		return nil, fmt.Errorf("encrypted value too small")
+	}
+	eph, err := ecdh.X25519().NewPublicKey(ciphertext[:x25519KeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	salt := append(eph.Bytes(), key.PublicKey().Bytes()...)
+	aead, err := x25519AEAD(key, eph, salt)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext[x25519KeySize:], data)
+}
+
+// x25519AEAD creates the cipher for the shared secret of the keys. The key is
+// derived with HKDF-SHA256 using the salt, which holds the ephemeral and
+// recipient public keys.
+func x25519AEAD(priv *ecdh.PrivateKey, pub *ecdh.PublicKey, salt []byte) (cipher.AEAD, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	_, err = io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(x25519Info)), key)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// parseX25519Private decodes a private key created by GenerateX25519Key.
+func parseX25519Private(private string) (*ecdh.PrivateKey, error) {
+	b, err := decodeKey(private)
+	if err == nil {
+		var key *ecdh.PrivateKey
+		key, err = ecdh.X25519().NewPrivateKey(b)
+		if err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid x25519 private key")
+}
+
+// encodeKey encodes a key as base64.
+func encodeKey(key []byte) string {
+	return base64.URLEncoding.EncodeToString(key)
+}
+
+// decodeKey decodes a base64 key, ignoring surrounding whitespace.
+func decodeKey(key string) ([]byte, error) {
+	return base64.URLEncoding.DecodeString(strings.TrimSpace(key))
+}
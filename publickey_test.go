@@ -0,0 +1,101 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptX25519(t *testing.T) {
+	pub, priv, err := GenerateX25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := X25519PublicKey(priv)
+	if derived != pub || err != nil {
+		t.Errorf("want: %s, err == nil; got: %s, %v", pub, derived, err)
+	}
+
+	enc, err := Encrypt(EncryptX25519, pub, "secret")
+	if !strings.HasPrefix(enc, EncryptX25519) || err != nil {
+		t.Fatalf("want: '[enc:6]...', err == nil; got: %s, %v", enc, err)
+	}
+	enc2, _ := Encrypt(EncryptX25519, pub, "secret")
+	if enc == enc2 {
+		t.Errorf("want: different values for each encryption; got: %s", enc)
+	}
+
+	dec, err := Decrypt(priv, enc)
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = Decrypt(pub, enc)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil with public key; got: %s, %v", dec, err)
+	}
+	_, other, _ := GenerateX25519Key()
+	dec, err = Decrypt(other, enc)
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil with other key; got: %s, %v", dec, err)
+	}
+
+	bound, err := EncryptBound(EncryptX25519Bound, pub, "secret", "db.password", "prod")
+	if !strings.HasPrefix(bound, EncryptX25519Bound) || err != nil {
+		t.Fatalf("want: '[enc:7]...', err == nil; got: %s, %v", bound, err)
+	}
+	dec, err = DecryptBound(priv, bound, "db.password", "prod")
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+	dec, err = DecryptBound(priv, bound, "admin.password", "prod")
+	if dec != "" || err == nil {
+		t.Errorf("want: '', err != nil for other key; got: %s, %v", dec, err)
+	}
+	_, err = Encrypt(EncryptX25519Bound, pub, "secret")
+	if err == nil {
+		t.Errorf("want: err != nil for unbound use of bound algorithm; got: nil")
+	}
+
+	kr := &Keyring{Primary: "ci", Keys: map[string]string{"ci": priv}}
+	enc, err = EncryptWith(&Keyring{Primary: "ci", Keys: map[string]string{"ci": pub}}, EncryptX25519, "secret")
+	if !strings.HasPrefix(enc, "[enc:6:ci]") || err != nil {
+		t.Fatalf("want: '[enc:6:ci]...', err == nil; got: %s, %v", enc, err)
+	}
+	dec, err = kr.Decrypt(enc)
+	if dec != "secret" || err != nil {
+		t.Errorf("want: 'secret', err == nil; got: %s, %v", dec, err)
+	}
+}
+
+func TestEncryptX25519Bad(t *testing.T) {
+	pub, priv, _ := GenerateX25519Key()
+
+	for _, key := range []string{"", "short", "$$$$", pub[:20]} {
+		_, err := Encrypt(EncryptX25519, key, "secret")
+		if err == nil {
+			t.Errorf("%s want: err != nil for invalid public key; got: nil", key)
+		}
+		_, err = X25519PublicKey(key)
+		if err == nil {
+			t.Errorf("%s want: err != nil for invalid private key; got: nil", key)
+		}
+	}
+
+	enc, _ := Encrypt(EncryptX25519, pub, "secret")
+	bad := []string{
+		EncryptX25519 + "AAAA",
+		enc[:len(enc)-8] + "AAAAAAA=",
+		EncryptX25519 + enc[len(EncryptX25519)+4:],
+	}
+	for _, val := range bad {
+		dec, err := Decrypt(priv, val)
+		if dec != "" || err == nil {
+			t.Errorf("%s want: '', err != nil; got: %s, %v", val, dec, err)
+		}
+	}
+	_, err := Decrypt("bad key", enc)
+	if err == nil {
+		t.Errorf("want: err != nil for invalid private key; got: nil")
+	}
+}
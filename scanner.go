@@ -18,6 +18,9 @@ const hexChars = "0123456789ABCDEFabcdef"
 type scanner struct {
 	p *Properties
 
+	// load adds the completed entries to p
+	load *loader
+
 	// the key and value for the current line
 	key   bytes.Buffer
 	value bytes.Buffer
@@ -84,7 +87,7 @@ func (s *scanner) checkEscape(ch rune) stateFunc {
 
 func (s *scanner) done() {
 	if s.key.Len() > 0 {
-		s.p.values[s.key.String()] = s.value.String()
+		s.load.add(s.key.String(), s.value.String())
 	}
 }
 
@@ -284,7 +287,7 @@ func stateUtfEscape(s *scanner, ch rune) stateFunc {
 // finishEntry handles the end of a property file entry and resets the
 // scanner for the next entry
 func finishEntry(s *scanner) stateFunc {
-	s.p.values[s.key.String()] = s.value.String()
+	s.load.add(s.key.String(), s.value.String())
 	s.key.Reset()
 	s.value.Reset()
 	s.current = &s.key
@@ -0,0 +1,92 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"fmt"
+)
+
+// redacted replaces the value of a Secret when it is formatted
+const redacted = "[REDACTED]"
+
+// Secret holds a decrypted value so that it is not logged or serialized by
+// accident. Formatting with the fmt package, String, GoString, MarshalJSON,
+// MarshalText, and slog (Go 1.21 and later) all produce "[REDACTED]". The value
+// is only available through Reveal and Bytes, and Destroy overwrites it with
+// zeros once it is no longer needed.
+//
+// A Secret should be passed by pointer, as returned by NewSecret and
+// Configuration.DecryptSecret, so that Destroy affects every use.
+type Secret struct {
+	b []byte
+}
+
+// NewSecret returns a Secret that takes ownership of the value. The caller
+// should not use the slice afterward since Destroy will zero it.
+func NewSecret(value []byte) *Secret {
+	return &Secret{b: value}
+}
+
+// Reveal returns the secret value as a string. The string is a copy that can
+// not be zeroed by Destroy, so prefer Bytes where possible. After Destroy, an
+// empty string is returned.
+func (s *Secret) Reveal() string {
+	if s == nil {
+		return ""
+	}
+	return string(s.b)
+}
+
+// Bytes returns a copy of the secret value. The caller is responsible for
+// zeroing the copy when it is no longer needed. After Destroy, nil is
+// returned.
+func (s *Secret) Bytes() []byte {
+	if s == nil || s.b == nil {
+		return nil
+	}
+	b := make([]byte, len(s.b))
+	copy(b, s.b)
+	return b
+}
+
+// Destroy overwrites the secret value with zeros and releases it. Later calls
+// to Reveal and Bytes return an empty value.
+func (s *Secret) Destroy() {
+	if s == nil {
+		return
+	}
+	for i := range s.b {
+		s.b[i] = 0
+	}
+	s.b = nil
+}
+
+// String returns "[REDACTED]".
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString returns a redacted Go representation for the %#v format.
+func (s Secret) GoString() string {
+	return "props.Secret{" + redacted + "}"
+}
+
+// Format writes the redacted value for every verb so that the value can not
+// be printed with verbs such as %x or %d.
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, s.GoString())
+		return
+	}
+	fmt.Fprint(f, redacted)
+}
+
+// MarshalJSON returns "[REDACTED]" as a JSON string.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText returns "[REDACTED]" for encoders such as YAML and TOML.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
@@ -0,0 +1,15 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+//go:build go1.21
+
+package props
+
+import "log/slog"
+
+// Ensure that Secret implements slog.LogValuer
+var _ slog.LogValuer = Secret{}
+
+// LogValue returns "[REDACTED]" so that the secret is not written to logs.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
@@ -0,0 +1,25 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+//go:build go1.21
+
+package props
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	s := NewSecret([]byte("hunter2"))
+	log.Info("connect", "password", s, "copy", *s)
+	log.Info("group", slog.Group("db", slog.Any("password", s)))
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") || strings.Count(out, redacted) != 3 {
+		t.Errorf("want: redacted log; got: %s", out)
+	}
+}
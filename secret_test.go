@@ -0,0 +1,114 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	buf := []byte("hunter2")
+	s := NewSecret(buf)
+
+	if got := s.Reveal(); got != "hunter2" {
+		t.Errorf("want: 'hunter2'; got: %s", got)
+	}
+	b := s.Bytes()
+	b[0] = 'X'
+	if got := s.Reveal(); got != "hunter2" {
+		t.Errorf("want: 'hunter2' after changing copy; got: %s", got)
+	}
+
+	formats := []string{"%s", "%v", "%+v", "%q", "%x", "%X", "%d", "%10s"}
+	for _, f := range formats {
+		for _, val := range []interface{}{s, *s, struct{ Pass *Secret }{s}} {
+			out := fmt.Sprintf(f, val)
+			if strings.Contains(out, "hunter2") || strings.Contains(out, fmt.Sprintf("%x", "hunter2")) ||
+				!strings.Contains(out, redacted) {
+				t.Errorf("%s want: redacted; got: %s", f, out)
+			}
+		}
+	}
+	if out := fmt.Sprintf("%#v", s); out != "props.Secret{[REDACTED]}" {
+		t.Errorf("want: 'props.Secret{[REDACTED]}'; got: %s", out)
+	}
+	if out := s.String(); out != redacted {
+		t.Errorf("want: '%s'; got: %s", redacted, out)
+	}
+
+	out, err := json.Marshal(map[string]interface{}{"ptr": s, "val": *s})
+	if string(out) != `{"ptr":"[REDACTED]","val":"[REDACTED]"}` || err != nil {
+		t.Errorf(`want: {"ptr":"[REDACTED]","val":"[REDACTED]"}, err == nil; got: %s, %v`, out, err)
+	}
+	text, err := s.MarshalText()
+	if string(text) != redacted || err != nil {
+		t.Errorf("want: '%s', err == nil; got: %s, %v", redacted, text, err)
+	}
+
+	s.Destroy()
+	if string(buf) != "\x00\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("want: zeroed buffer; got: %q", buf)
+	}
+	if got := s.Reveal(); got != "" {
+		t.Errorf("want: '' after Destroy; got: %s", got)
+	}
+	if got := s.Bytes(); got != nil {
+		t.Errorf("want: nil after Destroy; got: %v", got)
+	}
+	s.Destroy()
+
+	var nilSecret *Secret
+	if nilSecret.Reveal() != "" || nilSecret.Bytes() != nil {
+		t.Errorf("want: empty values for nil secret")
+	}
+	nilSecret.Destroy()
+}
+
+func TestConfigDecryptSecret(t *testing.T) {
+	defer func(iter int) { pbkdf2Iterations = iter }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	dbPass, _ := EncryptBound(EncryptAESGCMBound, "pass", "db-secret", "db.password", "prod")
+	kr := &Keyring{Primary: "k1", Keys: map[string]string{"k1": "pass"}}
+	apiKey, _ := kr.Encrypt(EncryptXChaCha20, "api-secret")
+
+	p := NewProperties()
+	p.Set("db.password", dbPass)
+	p.Set("api.key", apiKey)
+	p.Set("plain", "[enc:0]plain-secret")
+	p.Set("bad", "[enc:2]AAAA")
+	c := &Configuration{Props: p, BindContext: []string{"prod"}}
+
+	s, err := c.DecryptSecret("pass", "db.password")
+	if s.Reveal() != "db-secret" || err != nil {
+		t.Errorf("want: 'db-secret', err == nil; got: %s, %v", s.Reveal(), err)
+	}
+	s, err = c.DecryptSecret("pass", "plain")
+	if s.Reveal() != "plain-secret" || err != nil {
+		t.Errorf("want: 'plain-secret', err == nil; got: %s, %v", s.Reveal(), err)
+	}
+	s, err = c.DecryptSecret("pass", "missing")
+	if s != nil || err != nil {
+		t.Errorf("want: nil, err == nil; got: %v, %v", s, err)
+	}
+	s, err = c.DecryptSecret("wrong", "db.password")
+	if s != nil || err == nil {
+		t.Errorf("want: nil, err != nil; got: %v, %v", s, err)
+	}
+
+	s, err = c.DecryptSecretWith(kr, "api.key")
+	if s.Reveal() != "api-secret" || err != nil {
+		t.Errorf("want: 'api-secret', err == nil; got: %s, %v", s.Reveal(), err)
+	}
+	s, err = c.DecryptSecretWith(kr, "missing")
+	if s != nil || err != nil {
+		t.Errorf("want: nil, err == nil; got: %v, %v", s, err)
+	}
+	s, err = c.DecryptSecretWith(kr, "bad")
+	if s != nil || err == nil || strings.Contains(err.Error(), "AAAA") {
+		t.Errorf("want: nil, err != nil without value; got: %v, %v", s, err)
+	}
+}
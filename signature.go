@@ -0,0 +1,262 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// SignatureHeader starts the comment line that holds a file's signature, as
+// in "#[sig:ed25519]<base64 data>". The line is normally the last line of the
+// file (a trailer) or the only line of a sidecar file named "<file>.sig".
+const SignatureHeader = "#[sig:"
+
+var (
+	// ErrUnsigned is returned when verifying a file that has no signature.
+	ErrUnsigned = errors.New("missing signature")
+
+	// ErrSignature is returned when a file's signature does not match its
+	// properties.
+	ErrSignature = errors.New("invalid signature")
+)
+
+// Signer creates and checks signatures over the canonical form of a property
+// set (see Canonical).
+type Signer interface {
+	// Algorithm returns the name of the signature algorithm recorded in the
+	// signature line, such as "hmac-sha256".
+	Algorithm() string
+
+	// Sign returns the signature for the data.
+	Sign(data []byte) ([]byte, error)
+
+	// Verify returns ErrSignature if the signature does not match the data.
+	Verify(data, sig []byte) error
+}
+
+// HMACSigner signs property sets with HMAC-SHA256 using a shared secret key.
+type HMACSigner struct {
+	// Key is the secret key used to sign and verify.
+	Key []byte
+}
+
+// Ensure that HMACSigner implements Signer
+var _ Signer = &HMACSigner{}
+
+// Algorithm returns "hmac-sha256".
+func (h *HMACSigner) Algorithm() string {
+	return "hmac-sha256"
+}
+
+// Sign returns the HMAC of the data.
+func (h *HMACSigner) Sign(data []byte) ([]byte, error) {
+	if len(h.Key) == 0 {
+		return nil, fmt.Errorf("missing hmac key")
+	}
+	mac := hmac.New(sha256.New, h.Key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+// Verify checks the HMAC of the data.
+func (h *HMACSigner) Verify(data, sig []byte) error {
+	want, err := h.Sign(data)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(want, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Ed25519Signer signs property sets with an Ed25519 private key. Only the
+// public key is needed to verify signatures.
+type Ed25519Signer struct {
+	// PrivateKey is used to sign. It is not needed to verify.
+	PrivateKey ed25519.PrivateKey
+
+	// PublicKey is used to verify. If it is nil, the public key of PrivateKey
+	// is used.
+	PublicKey ed25519.PublicKey
+}
+
+// Ensure that Ed25519Signer implements Signer
+var _ Signer = &Ed25519Signer{}
+
+// Algorithm returns "ed25519".
+func (e *Ed25519Signer) Algorithm() string {
+	return "ed25519"
+}
+
+// Sign returns the signature of the data.
+func (e *Ed25519Signer) Sign(data []byte) ([]byte, error) {
+	if len(e.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key")
+	}
+	return ed25519.Sign(e.PrivateKey, data), nil
+}
+
+// Verify checks the signature of the data.
+func (e *Ed25519Signer) Verify(data, sig []byte) error {
+	pub := e.PublicKey
+	if pub == nil && len(e.PrivateKey) == ed25519.PrivateKeySize {
+		pub = e.PrivateKey.Public().(ed25519.PublicKey)
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key")
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Canonical returns the canonical form of a property set that is signed. Keys
+// are sorted and each key and value is prefixed by its length, so the result
+// does not depend on the file format, comments, or the order of the keys. If
+// the property set is a MultiGetter, every value of a key is included.
+func Canonical(p PropertyGetter) []byte {
+	names := p.Names()
+	sort.Strings(names)
+
+	data := []byte("props-signature-v1")
+	field := func(s string) {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(s)))
+		data = append(data, s...)
+	}
+	mg, multi := p.(MultiGetter)
+	for _, name := range names {
+		var vals []string
+		if multi {
+			vals = mg.GetAll(name)
+		} else if val, ok := p.Get(name); ok {
+			vals = []string{val}
+		}
+		field(name)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(vals)))
+		for _, val := range vals {
+			field(val)
+		}
+	}
+	return data
+}
+
+// SignatureLine returns the signature line for the property set.
+func SignatureLine(s Signer, p PropertyGetter) (string, error) {
+	sig, err := s.Sign(Canonical(p))
+	if err != nil {
+		return "", err
+	}
+	return SignatureHeader + s.Algorithm() + "]" + base64.URLEncoding.EncodeToString(sig), nil
+}
+
+// IsSignatureLine determines whether the line is a signature line.
+func IsSignatureLine(line string) bool {
+	return strings.HasPrefix(line, SignatureHeader)
+}
+
+// FileSignature returns the signature line for the contents of a property
+// file, such as for a sidecar file. The file name is used to select the file
+// format by extension.
+func FileSignature(s Signer, name string, data []byte) (string, error) {
+	p, err := documentReader(name)(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s [%w]", name, err)
+	}
+	line, err := SignatureLine(s, p)
+	if err != nil {
+		return "", fmt.Errorf("unable to sign %s [%w]", name, err)
+	}
+	return line, nil
+}
+
+// SignFile returns the contents of the property file with its signature as a
+// trailer comment. Any existing signature lines are replaced. The file name is
+// used to select the file format by extension. JSON files do not support
+// comments and must use a sidecar file with FileSignature.
+func SignFile(s Signer, name string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".json") {
+		return nil, fmt.Errorf("unable to sign %s: json files require a sidecar signature", name)
+	}
+	line, err := FileSignature(s, name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result bytes.Buffer
+	for _, l := range strings.SplitAfter(string(data), "\n") {
+		if l != "" && !IsSignatureLine(l) {
+			result.WriteString(l)
+		}
+	}
+	if result.Len() > 0 && !bytes.HasSuffix(result.Bytes(), []byte("\n")) {
+		result.WriteByte('\n')
+	}
+	result.WriteString(line)
+	result.WriteByte('\n')
+	return result.Bytes(), nil
+}
+
+// VerifyFile reads the property file and verifies its signature. The signature
+// is taken from a signature line in the file or, if there is none, from the
+// sidecar contents, which may be nil. The file name is used to select the file
+// format by extension.
+//
+// ErrUnsigned is returned if there is no signature and ErrSignature is
+// returned if the signature does not match.
+func VerifyFile(s Signer, name string, data, sidecar []byte) (*Properties, error) {
+	line := ""
+	for _, l := range strings.Split(string(data), "\n") {
+		if IsSignatureLine(l) {
+			line = l
+		}
+	}
+	if line == "" {
+		line = strings.TrimSpace(string(sidecar))
+	}
+	if !IsSignatureLine(line) {
+		return nil, fmt.Errorf("unable to verify %s [%w]", name, ErrUnsigned)
+	}
+
+	alg, encoded, _ := strings.Cut(strings.TrimSpace(line[len(SignatureHeader):]), "]")
+	if alg != s.Algorithm() {
+		return nil, fmt.Errorf("unable to verify %s: %s signature [%w]", name, alg, ErrSignature)
+	}
+	sig, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify %s [%w]", name, ErrSignature)
+	}
+
+	p, err := documentReader(name)(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s [%w]", name, err)
+	}
+	err = s.Verify(Canonical(p), sig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify %s [%w]", name, err)
+	}
+	return p, nil
+}
+
+// LoadVerified reads a property file and verifies its signature as described
+// by VerifyFile. A sidecar file named "<name>.sig" is used if the file does not
+// contain a signature line.
+func LoadVerified(fileSys fs.FS, name string, s Signer) (*Properties, error) {
+	data, err := fs.ReadFile(fileSys, name)
+	if err != nil {
+		return nil, err
+	}
+	sidecar, _ := fs.ReadFile(fileSys, name+".sig")
+	return VerifyFile(s, name, data, sidecar)
+}
@@ -0,0 +1,166 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSignFile(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	signers := []Signer{
+		&HMACSigner{Key: []byte("secret")},
+		&Ed25519Signer{PrivateKey: priv},
+	}
+	verifiers := []Signer{
+		&HMACSigner{Key: []byte("secret")},
+		&Ed25519Signer{PublicKey: pub},
+	}
+
+	files := map[string]string{
+		"app.properties": "# settings\ntls.verify=true\nport=8080\n",
+		"app.yaml":       "tls:\n  verify: true\nport: 8080\n",
+		"app.toml":       "port = 8080\n[tls]\nverify = true",
+		"app.ini":        "port = 8080\n[tls]\nverify = true\n",
+		"app.env":        "PORT=8080\nTLS_VERIFY=true\n",
+	}
+	for i, s := range signers {
+		for name, data := range files {
+			signed, err := SignFile(s, name, []byte(data))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(signed)), "\n")
+			if !strings.HasPrefix(lines[len(lines)-1], SignatureHeader+s.Algorithm()+"]") {
+				t.Errorf("%s want: signature trailer; got: %s", name, signed)
+			}
+			again, err := SignFile(s, name, signed)
+			if err != nil || strings.Count(string(again), SignatureHeader) != 1 {
+				t.Errorf("%s want: one signature line, err == nil; got: %s, %v", name, again, err)
+			}
+
+			p, err := VerifyFile(verifiers[i], name, signed, nil)
+			if p == nil || err != nil {
+				t.Errorf("%s want: properties, err == nil; got: %v, %v", name, p, err)
+			}
+
+			// comments and reordering do not change the property set
+			reordered := "# comment\n" + string(signed)
+			if name == "app.properties" {
+				reordered = "port=8080\n" + strings.Replace(string(signed), "port=8080\n", "", 1)
+			}
+			_, err = VerifyFile(verifiers[i], name, []byte(reordered), nil)
+			if err != nil {
+				t.Errorf("%s want: err == nil after reordering; got: %v", name, err)
+			}
+
+			tampered := strings.Replace(string(signed), "true", "false", 1)
+			p, err = VerifyFile(verifiers[i], name, []byte(tampered), nil)
+			if p != nil || !errors.Is(err, ErrSignature) {
+				t.Errorf("%s want: nil, ErrSignature; got: %v, %v", name, p, err)
+			}
+		}
+	}
+
+	_, err := SignFile(signers[0], "app.json", []byte(`{"a": 1}`))
+	if err == nil {
+		t.Errorf("want: err != nil for json trailer; got: nil")
+	}
+	_, err = SignFile(&Ed25519Signer{PublicKey: pub}, "app.properties", []byte("a=1"))
+	if err == nil {
+		t.Errorf("want: err != nil without private key; got: nil")
+	}
+	_, err = SignFile(&HMACSigner{}, "app.properties", []byte("a=1"))
+	if err == nil {
+		t.Errorf("want: err != nil without hmac key; got: nil")
+	}
+	_, err = SignFile(signers[0], "app.ini", []byte("[tls\nverify"))
+	if err == nil {
+		t.Errorf("want: err != nil for invalid file; got: nil")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	s := &HMACSigner{Key: []byte("secret")}
+	data := []byte(`{"tls": {"verify": true}}`)
+	sidecar, err := FileSignature(s, "app.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := VerifyFile(s, "app.json", data, []byte(sidecar+"\n"))
+	if val, _ := p.Get("tls.verify"); val != "true" || err != nil {
+		t.Errorf("want: 'true', err == nil; got: %s, %v", val, err)
+	}
+
+	tests := []struct {
+		data    string
+		sidecar string
+		want    error
+	}{
+		{`{"tls": {"verify": false}}`, sidecar, ErrSignature},
+		{string(data), "", ErrUnsigned},
+		{string(data), "garbage", ErrUnsigned},
+		{string(data), SignatureHeader + "ed25519]" + sidecar[len(SignatureHeader+"hmac-sha256]"):], ErrSignature},
+		{string(data), SignatureHeader + "hmac-sha256]$$$$", ErrSignature},
+	}
+	for i, test := range tests {
+		p, err := VerifyFile(s, "app.json", []byte(test.data), []byte(test.sidecar))
+		if p != nil || !errors.Is(err, test.want) {
+			t.Errorf("%d want: nil, %v; got: %v, %v", i, test.want, p, err)
+		}
+	}
+
+	_, err = VerifyFile(&Ed25519Signer{}, "app.json", data, []byte(SignatureHeader+"ed25519]AAAA"))
+	if err == nil {
+		t.Errorf("want: err != nil without public key; got: nil")
+	}
+}
+
+func TestLoadVerified(t *testing.T) {
+	s := &HMACSigner{Key: []byte("secret")}
+	signed, _ := SignFile(s, "app.properties", []byte("tls.verify=true\n"))
+	doc := []byte(`{"port": 8080}`)
+	sidecar, _ := FileSignature(s, "app-prod.json", doc)
+
+	fsys := fstest.MapFS{
+		"app.properties":    &fstest.MapFile{Data: signed},
+		"app-prod.json":     &fstest.MapFile{Data: doc},
+		"app-prod.json.sig": &fstest.MapFile{Data: []byte(sidecar)},
+		"other.properties":  &fstest.MapFile{Data: []byte("tls.verify=false\n")},
+	}
+
+	p, err := LoadVerified(fsys, "app.properties", s)
+	if val, _ := p.Get("tls.verify"); val != "true" || err != nil {
+		t.Errorf("want: 'true', err == nil; got: %s, %v", val, err)
+	}
+	_, err = LoadVerified(fsys, "other.properties", s)
+	if !errors.Is(err, ErrUnsigned) {
+		t.Errorf("want: ErrUnsigned; got: %v", err)
+	}
+	_, err = LoadVerified(fsys, "missing.properties", s)
+	if err == nil {
+		t.Errorf("want: err != nil for missing file; got: nil")
+	}
+
+	c, err := NewConfigurationWith(fsys, ConfigOptions{Signer: s}, "app", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := c.GetDefault("port", ""); val != "8080" {
+		t.Errorf("want: '8080'; got: %s", val)
+	}
+
+	_, err = NewConfigurationWith(fsys, ConfigOptions{Signer: s}, "other")
+	if !errors.Is(err, ErrUnsigned) {
+		t.Errorf("want: ErrUnsigned; got: %v", err)
+	}
+	_, err = NewConfigurationWith(fsys, ConfigOptions{Signer: &HMACSigner{Key: []byte("wrong")}}, "app")
+	if !errors.Is(err, ErrSignature) {
+		t.Errorf("want: ErrSignature; got: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs returns the functions used to access property values from a
+// text/template. Typed values are parsed as described by the corresponding
+// Configuration functions.
+//
+// The functions provided are:
+//
+//	prop "key"                  - value of a property; an error if missing
+//	propDefault "key" "def"     - value of a property or the default
+//	hasProp "key"               - whether the property exists
+//	propInt "key" 0             - value as an int (see ParseInt)
+//	propFloat "key" 0.0         - value as a float64 (see ParseFloat)
+//	propBool "key" false        - value as a bool (see ParseBool)
+//	propDuration "key" "1s"     - value as a time.Duration (see ParseDuration)
+//	propByteSize "key" 0        - value as a uint64 (see ParseByteSize)
+//	propSize "key" 0.0          - value as a float64 (see ParseSize)
+//	propDate "key" "2006-01-02" - value as a time.Time (see ParseDate)
+//
+// For example:
+//
+//	server {
+//	    listen {{ propInt "http.port" 80 }};
+//	    server_name {{ prop "site.host" }};
+//	    {{- if hasProp "tls.cert" }}
+//	    ssl_certificate {{ prop "tls.cert" }};
+//	    {{- end }}
+//	}
+func TemplateFuncs(p PropertyGetter) template.FuncMap {
+	c, ok := p.(*Configuration)
+	if !ok {
+		c = &Configuration{Props: p}
+	}
+
+	return template.FuncMap{
+		"prop": func(key string) (string, error) {
+			v, ok := c.Get(key)
+			if !ok {
+				return "", fmt.Errorf("missing property %s", key)
+			}
+			return v, nil
+		},
+		"propDefault": func(key, defVal string) string {
+			return c.GetDefault(key, defVal)
+		},
+		"hasProp": func(key string) bool {
+			_, ok := c.Get(key)
+			return ok
+		},
+		"propInt": func(key string, defVal int) (int, error) {
+			return c.ParseInt(key, defVal)
+		},
+		"propFloat": func(key string, defVal float64) (float64, error) {
+			return c.ParseFloat(key, defVal)
+		},
+		"propBool": func(key string, defVal bool) (bool, error) {
+			return c.ParseBool(key, defVal)
+		},
+		"propDuration": func(key string, defVal string) (time.Duration, error) {
+			def, err := time.ParseDuration(defVal)
+			if err != nil {
+				return 0, fmt.Errorf("invalid default duration %s [%w]", defVal, err)
+			}
+			return c.ParseDuration(key, def)
+		},
+		"propByteSize": func(key string, defVal uint64) (uint64, error) {
+			return c.ParseByteSize(key, defVal)
+		},
+		"propSize": func(key string, defVal float64) (float64, error) {
+			return c.ParseSize(key, defVal)
+		},
+		"propDate": func(key string, defVal string) (time.Time, error) {
+			layout := c.DateFormat
+			if layout == "" {
+				layout = "2006-01-02"
+			}
+			def, err := time.Parse(layout, defVal)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid default date %s [%w]", defVal, err)
+			}
+			return c.ParseDate(key, def)
+		},
+	}
+}
+
+// NewTemplate creates an empty text/template with the given name and the
+// property functions from TemplateFuncs.
+func NewTemplate(name string, p PropertyGetter) *template.Template {
+	return template.New(name).Funcs(TemplateFuncs(p))
+}
+
+// RenderTemplate parses the text as a text/template and writes the result of
+// executing it to w. Property values are accessed with the functions provided
+// by TemplateFuncs.
+func RenderTemplate(w io.Writer, name, text string, p PropertyGetter) error {
+	t, err := NewTemplate(name, p).Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, nil)
+}
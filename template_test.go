@@ -0,0 +1,78 @@
+// (c) 2026 Rick Arnold. Licensed under the BSD license (see LICENSE).
+
+package props
+
+import (
+	"bytes"
+	"testing"
+)
+
+var templateTests = []struct {
+	text    string
+	want    string
+	wantErr bool
+}{
+	{`{{ prop "host" }}`, "localhost", false},
+	{`{{ prop "none" }}`, "", true},
+	{`{{ propDefault "none" "def" }}`, "def", false},
+	{`{{ propDefault "host" "def" }}`, "localhost", false},
+	{`{{ if hasProp "host" }}yes{{ end }}{{ if hasProp "none" }}no{{ end }}`, "yes", false},
+	{`{{ propInt "port" 80 }}`, "8080", false},
+	{`{{ propInt "none" 80 }}`, "80", false},
+	{`{{ propInt "host" 80 }}`, "", true},
+	{`{{ propFloat "ratio" 1.0 }}`, "0.5", false},
+	{`{{ if propBool "tls" false }}on{{ end }}`, "on", false},
+	{`{{ propDuration "timeout" "1s" }}`, "1m30s", false},
+	{`{{ propDuration "none" "1s" }}`, "1s", false},
+	{`{{ propDuration "none" "bad" }}`, "", true},
+	{`{{ propByteSize "buffer" 0 }}`, "2048", false},
+	{`{{ propSize "rate" 0.0 }}`, "1500", false},
+	{`{{ (propDate "start" "2000-01-01").Year }}`, "2022", false},
+	{`{{ (propDate "none" "2000-01-01").Year }}`, "2000", false},
+	{`{{ propDate "none" "bad" }}`, "", true},
+	{`{{ prop "url" }}`, "http://localhost:8080/", false},
+	{`{{ prop "host" `, "", true},
+}
+
+func TestRenderTemplate(t *testing.T) {
+	p := NewProperties()
+	p.Set("host", "localhost")
+	p.Set("port", "8080")
+	p.Set("ratio", "0.5")
+	p.Set("tls", "yes")
+	p.Set("timeout", "90s")
+	p.Set("buffer", "2Ki")
+	p.Set("rate", "1.5k")
+	p.Set("start", "2022-03-04")
+	p.Set("url", "http://${host}:${port}/")
+
+	c := &Configuration{Props: NewExpander(p)}
+	for _, test := range templateTests {
+		var buf bytes.Buffer
+		err := RenderTemplate(&buf, "test", test.text, c)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s want: error; got: nil", test.text)
+			}
+			continue
+		}
+		if err != nil || buf.String() != test.want {
+			t.Errorf("%s want: %q, nil; got: %q, %v", test.text, test.want, buf.String(), err)
+		}
+	}
+}
+
+func TestNewTemplate(t *testing.T) {
+	p := NewProperties()
+	p.Set("host", "localhost")
+
+	tmpl, err := NewTemplate("test", p).Parse(`server_name {{ prop "host" }};`)
+	if err != nil {
+		t.Fatalf("got error: %v", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, nil)
+	if err != nil || buf.String() != "server_name localhost;" {
+		t.Errorf("want: 'server_name localhost;', nil; got: %q, %v", buf.String(), err)
+	}
+}